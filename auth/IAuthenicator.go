@@ -1,6 +1,13 @@
 package auth
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrUnavailable is wrapped by authenticators, if credentials could not be verified
+// because of an infrastructure failure (e.g. the JWKS endpoint is not reachable)
+var ErrUnavailable = errors.New("authenticator unavailable")
 
 type IAuthenticator[T any] interface {
 	GetIdentityFromAuthorizationHeader(httpHeader http.Header) (T, error)
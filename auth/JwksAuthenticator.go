@@ -130,7 +130,7 @@ func (a *JwksAuthenticator[T]) getJwkSet() (jwk.Set, error) {
 		defer cancel()
 		set, err := jwk.Fetch(ctx, a.jwksUri)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w - failed to fetch jwks - %v", ErrUnavailable, err)
 		}
 		a.jwkSet = set
 	}
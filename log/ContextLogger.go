@@ -0,0 +1,60 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uoul/go-common/tracing"
+)
+
+// ContextLogger prefixes all messages with request id and trace id of a context
+type ContextLogger struct {
+	logger ILogger
+	prefix string
+}
+
+func (l *ContextLogger) Trace(message string)   { l.logger.Trace(l.prefix + message) }
+func (l *ContextLogger) Debug(message string)   { l.logger.Debug(l.prefix + message) }
+func (l *ContextLogger) Info(message string)    { l.logger.Info(l.prefix + message) }
+func (l *ContextLogger) Warning(message string) { l.logger.Warning(l.prefix + message) }
+func (l *ContextLogger) Error(message string)   { l.logger.Error(l.prefix + message) }
+func (l *ContextLogger) Fatal(message string)   { l.logger.Fatal(l.prefix + message) }
+
+func (l *ContextLogger) Tracef(format string, a ...any) {
+	l.logger.Trace(l.prefix + fmt.Sprintf(format, a...))
+}
+func (l *ContextLogger) Debugf(format string, a ...any) {
+	l.logger.Debug(l.prefix + fmt.Sprintf(format, a...))
+}
+func (l *ContextLogger) Infof(format string, a ...any) {
+	l.logger.Info(l.prefix + fmt.Sprintf(format, a...))
+}
+func (l *ContextLogger) Warningf(format string, a ...any) {
+	l.logger.Warning(l.prefix + fmt.Sprintf(format, a...))
+}
+func (l *ContextLogger) Errorf(format string, a ...any) {
+	l.logger.Error(l.prefix + fmt.Sprintf(format, a...))
+}
+func (l *ContextLogger) Fatalf(format string, a ...any) {
+	l.logger.Fatal(l.prefix + fmt.Sprintf(format, a...))
+}
+
+// WithContext returns a logger, that prefixes all messages with the request id and trace
+// id stored in the context (see package tracing). Without both, the logger is returned.
+func WithContext(logger ILogger, ctx context.Context) ILogger {
+	fields := []string{}
+	if requestId := tracing.RequestId(ctx); requestId != "" {
+		fields = append(fields, "request_id="+requestId)
+	}
+	if traceParent, ok := tracing.GetTraceParent(ctx); ok {
+		fields = append(fields, "trace_id="+traceParent.TraceId)
+	}
+	if len(fields) == 0 {
+		return logger
+	}
+	return &ContextLogger{
+		logger: logger,
+		prefix: fmt.Sprintf("[%s] ", strings.Join(fields, " ")),
+	}
+}
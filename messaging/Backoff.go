@@ -0,0 +1,86 @@
+package messaging
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// -----------------------------------------------------------------------------------
+// Type
+// -----------------------------------------------------------------------------------
+
+type ConstantBackoff struct {
+	interval time.Duration
+}
+
+type ExponentialBackoff struct {
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+	jitter     float64
+}
+
+// -----------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------
+
+// Next implements IBackoff.
+func (b *ConstantBackoff) Next(attempt uint) time.Duration {
+	return b.interval
+}
+
+// Next implements IBackoff.
+func (b *ExponentialBackoff) Next(attempt uint) time.Duration {
+	d := float64(b.initial) * math.Pow(b.multiplier, float64(attempt))
+	if math.IsInf(d, 0) || math.IsNaN(d) || d > float64(b.max) {
+		d = float64(b.max)
+	}
+	// Spread delay randomly by +/- jitter, so clients do not retry in lockstep
+	if b.jitter > 0 {
+		delta := d * b.jitter
+		d = d - delta + rand.Float64()*2*delta
+	}
+	return time.Duration(min(d, float64(b.max)))
+}
+
+// -----------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------
+
+// WithBackoffMultiplier sets the factor the delay grows by per attempt (default 2)
+func WithBackoffMultiplier(multiplier float64) func(*ExponentialBackoff) {
+	return func(b *ExponentialBackoff) {
+		b.multiplier = multiplier
+	}
+}
+
+// WithBackoffJitter sets the random spread as fraction of the delay, e.g. 0.2 for +/-20% (default 0.2)
+func WithBackoffJitter(jitter float64) func(*ExponentialBackoff) {
+	return func(b *ExponentialBackoff) {
+		b.jitter = jitter
+	}
+}
+
+// -----------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------
+
+func NewConstantBackoff(interval time.Duration) IBackoff {
+	return &ConstantBackoff{
+		interval: interval,
+	}
+}
+
+func NewExponentialBackoff(initial time.Duration, max time.Duration, opts ...func(*ExponentialBackoff)) IBackoff {
+	b := &ExponentialBackoff{
+		initial:    initial,
+		max:        max,
+		multiplier: 2,
+		jitter:     0.2,
+	}
+	for _, o := range opts {
+		o(b)
+	}
+	return b
+}
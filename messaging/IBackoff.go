@@ -0,0 +1,8 @@
+package messaging
+
+import "time"
+
+type IBackoff interface {
+	// Next returns the delay to wait before the given attempt (starting at 0)
+	Next(attempt uint) time.Duration
+}
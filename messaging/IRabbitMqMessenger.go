@@ -0,0 +1,28 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/uoul/go-common/async"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+type IRabbitMqMessenger interface {
+	IMessenger[RabbitMqExchange, amqp.Delivery]
+
+	// PublishContext publishes like Publish and propagates request id and trace context
+	// of ctx as message headers (see DeliveryContext). It is the only way to propagate
+	// them, Publish sends messages without tracing headers. If the send buffer is full (e.g.
+	// while disconnected), it waits until ctx is done and returns ctx.Err().
+	PublishContext(ctx context.Context, topic RabbitMqExchange, msg any) error
+
+	// State returns the current connection state and the cause of the last disconnect
+	State() (RabbitMqState, error)
+	// SubscribeState returns a stream of connection and subscription state events
+	SubscribeState() async.Stream[RabbitMqStateEvent]
+	UnsubscribeState(stream async.Stream[RabbitMqStateEvent])
+	// ReadinessCheck returns an error while not connected, so it can be registered
+	// with health.HealthMonitor.RegisterReadynessCheck
+	ReadinessCheck() error
+}
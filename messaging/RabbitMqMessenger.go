@@ -1,9 +1,15 @@
 package messaging
 
 import (
+	"cmp"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
-	"reflect"
+	"net/url"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/uoul/go-common/async"
@@ -23,15 +29,32 @@ type RabbitMqMessenger struct {
 	user     string
 	password string
 
+	uri            string
+	vhost          string
+	tlsConfig      *tls.Config
+	tlsCaFile      string
+	tlsCertFile    string
+	tlsKeyFile     string
+	tlsServerName  string
+	heartbeat      time.Duration
+	connectionName string
+
 	ctx    context.Context
 	logger log.ILogger
 
-	maxRetries    uint
-	retryInterval time.Duration
-	streamBuffer  uint
-	serializer    serialization.ISerializer
+	maxRetries       uint
+	reconnectBackoff IBackoff
+	publishBackoff   IBackoff
+	deadLetter       func(topic RabbitMqExchange, body []byte, err error)
+	streamBuffer     uint
+	serializer       serialization.ISerializer
+
+	subscriptions map[async.Stream[amqp.Delivery]]*subsciption
 
-	subscriptions map[async.Stream[amqp.Delivery]]subsciption
+	stateMux       sync.RWMutex
+	state          RabbitMqState
+	stateCause     error
+	stateObservers map[async.Stream[RabbitMqStateEvent]]bool
 
 	addSub    chan subsciptionReq
 	removeSub chan async.Stream[amqp.Delivery]
@@ -42,6 +65,20 @@ type RabbitMqExchange struct {
 	Type       string
 	Exchange   string
 	RoutingKey string
+
+	Durable    bool       // Survive broker restarts
+	AutoDelete bool       // Delete exchange, when the last queue unbinds
+	Internal   bool       // Exchange does not accept publishings
+	Arguments  amqp.Table // Optional arguments (e.g. alternate-exchange)
+	Passive    bool       // Do not declare, but fail if the exchange does not exist
+}
+
+// exchangeDeclarer declares exchanges once per connection. Each declaration runs on a
+// short-lived channel, so that a failing declaration does not close shared channels.
+type exchangeDeclarer struct {
+	conn     *amqp.Connection
+	mux      sync.Mutex
+	declared map[string]bool
 }
 
 type subsciptionReq struct {
@@ -49,16 +86,19 @@ type subsciptionReq struct {
 	sub      async.Stream[amqp.Delivery]
 }
 
+// subsciption consumes on its own channel, so that a failing declaration or binding
+// closes only the channel of this subscription
 type subsciption struct {
-	caseIdx  int
 	exchange RabbitMqExchange
-	queue    *amqp.Queue
-	consumer <-chan amqp.Delivery
+	ch       *amqp.Channel
+	queue    string
+	stop     chan struct{}
 }
 
 type internalMsg struct {
 	Exchange RabbitMqExchange
 	Body     []byte
+	Headers  amqp.Table
 	Retries  uint
 }
 
@@ -66,17 +106,31 @@ type internalMsg struct {
 // Public
 // -----------------------------------------------------------------------------------
 
-// Publish implements IMessenger.
+// Publish implements IMessenger. It propagates no request id or trace context, use
+// PublishContext for messages published while handling a request.
 func (r *RabbitMqMessenger) Publish(topic RabbitMqExchange, msg any) error {
+	return r.PublishContext(context.Background(), topic, msg)
+}
+
+// PublishContext implements IRabbitMqMessenger.
+func (r *RabbitMqMessenger) PublishContext(ctx context.Context, topic RabbitMqExchange, msg any) error {
 	serializedMsg, err := r.serializer.Marshal(msg)
 	if err != nil {
 		return err
 	}
-	r.sendMsg <- internalMsg{
+	// The buffer fills up while disconnected, ctx bounds the wait for a free slot
+	select {
+	case r.sendMsg <- internalMsg{
 		Exchange: topic,
 		Body:     serializedMsg,
+		Headers:  tracingHeaders(ctx),
+	}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.ctx.Done():
+		return fmt.Errorf("rabbitmq messenger is closed - %v", r.ctx.Err())
 	}
-	return nil
 }
 
 // Subscribe implements IMessenger.
@@ -94,154 +148,365 @@ func (r *RabbitMqMessenger) Unsubscribe(subsciption async.Stream[amqp.Delivery])
 	r.removeSub <- subsciption
 }
 
+// State implements IRabbitMqMessenger.
+func (r *RabbitMqMessenger) State() (RabbitMqState, error) {
+	r.stateMux.RLock()
+	defer r.stateMux.RUnlock()
+	return r.state, r.stateCause
+}
+
+// SubscribeState implements IRabbitMqMessenger.
+func (r *RabbitMqMessenger) SubscribeState() async.Stream[RabbitMqStateEvent] {
+	stream := async.NewBufferedStream[RabbitMqStateEvent](r.streamBuffer)
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	r.stateObservers[stream] = true
+	return stream
+}
+
+// UnsubscribeState implements IRabbitMqMessenger.
+func (r *RabbitMqMessenger) UnsubscribeState(stream async.Stream[RabbitMqStateEvent]) {
+	r.stateMux.Lock()
+	defer r.stateMux.Unlock()
+	delete(r.stateObservers, stream)
+}
+
+// ReadinessCheck implements IRabbitMqMessenger.
+func (r *RabbitMqMessenger) ReadinessCheck() error {
+	state, cause := r.State()
+	if state == RABBITMQ_CONNECTED {
+		return nil
+	}
+	if cause != nil {
+		return fmt.Errorf("rabbitmq %s - %v", state, cause)
+	}
+	return fmt.Errorf("rabbitmq %s", state)
+}
+
 // -----------------------------------------------------------------------------------
 // Private
 // -----------------------------------------------------------------------------------
-func (r *RabbitMqMessenger) run() error {
+func (r *RabbitMqMessenger) run() (connected bool, err error) {
+	r.setState(RABBITMQ_CONNECTING, nil)
+	defer func() {
+		if err != nil {
+			r.setState(RABBITMQ_DISCONNECTED, err)
+		} else {
+			r.setState(RABBITMQ_DISCONNECTED, r.ctx.Err())
+		}
+	}()
 	// Connect to rabbitmq
-	conn, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%d", r.user, r.password, r.host, r.port))
+	uri, err := r.connectionUri()
 	if err != nil {
-		return err
+		return connected, err
+	}
+	conn, err := r.dial(uri)
+	if err != nil {
+		return connected, err
 	}
 	defer conn.Close()
-	// Create channel
-	ch, err := conn.Channel()
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	// Create separate channel for publishing, so slow consumers do not block publishers
+	pubCh, err := conn.Channel()
 	if err != nil {
-		return err
+		return connected, err
 	}
-	defer ch.Close()
-	channelClosed := make(chan *amqp.Error, 1)
-	ch.NotifyClose(channelClosed)
+	defer pubCh.Close()
+	pubChClosed := pubCh.NotifyClose(make(chan *amqp.Error, 1))
+	// Stop all consumer workers when leaving, they are restarted on reconnect
+	workers := &sync.WaitGroup{}
+	defer workers.Wait()
+	defer r.stopSubscriptions()
 	// Init already registered subs
-	r.initCurrentSubscriptions(ch)
-	r.logger.Infof("Connection to rabbitmq(host: %s, port: %d) estabished", r.host, r.port)
+	declarer := newExchangeDeclarer(conn)
+	r.initCurrentSubscriptions(conn, declarer, workers)
+	// Run publisher
+	publishErr := make(chan error, 1)
+	publisherDone := make(chan struct{})
+	defer func() { <-publisherDone }()
+	stopPublisher := make(chan struct{})
+	defer close(stopPublisher)
+	go func() {
+		defer close(publisherDone)
+		publishErr <- r.publish(pubCh, declarer, stopPublisher)
+	}()
+	r.logger.Infof("Connection to rabbitmq(host: %s, port: %d, vhost: %s) estabished", uri.Host, uri.Port, uri.Vhost)
+	r.setState(RABBITMQ_CONNECTED, nil)
+	connected = true
 	// Run
 	for {
-		// Create select-cases
-		cases := r.createSelectCases(channelClosed)
-		idx, value, _ := reflect.Select(cases)
-		switch idx {
-		// Case 0: Parent context done
-		case 0:
-			return nil
-		// Case 1: Connection closed
-		case 1:
-			err, ok := value.Interface().(*amqp.Error)
-			if !ok {
-				return fmt.Errorf("failed to cast message from close channel")
+		select {
+		// Parent context done
+		case <-r.ctx.Done():
+			return connected, nil
+		// Connection or channel closed
+		case err := <-connClosed:
+			return connected, channelClosedError(err)
+		case err := <-pubChClosed:
+			return connected, channelClosedError(err)
+		// Publisher failed
+		case err := <-publishErr:
+			return connected, err
+		// Add subscription
+		case req := <-r.addSub:
+			r.subscriptions[req.sub] = &subsciption{
+				exchange: req.exchange,
 			}
-			return err
-		// Case 2: Send message
-		case 2:
-			msg, ok := value.Interface().(internalMsg)
-			if !ok {
-				r.logger.Warning("failed to cast message for sending to rabbitmq")
+			if err := r.declareAndBindQueueForSub(conn, declarer, req.sub, workers); err != nil {
+				r.logger.Warningf("failed to subscribe to rabbitmq (exchange=%s, routingKey=%s) - %v", req.exchange.Exchange, req.exchange.RoutingKey, err)
+			}
+		// Remove subsciption
+		case stream := <-r.removeSub:
+			sub, exists := r.subscriptions[stream]
+			if !exists {
 				continue
 			}
-			err := ch.ExchangeDeclare(
-				msg.Exchange.Exchange, // name
-				msg.Exchange.Type,     // type
-				false,                 // durable
-				true,                  // auto-deleted
-				false,                 // internal
-				false,                 // no-wait
-				nil,                   // arguments
-			)
-			if err != nil {
-				return err
+			delete(r.subscriptions, stream)
+			if sub.stop != nil {
+				close(sub.stop)
+				sub.ch.QueueDelete(sub.queue, false, false, true)
+				sub.ch.Close()
 			}
-			err = ch.Publish(
-				msg.Exchange.Exchange,
-				msg.Exchange.RoutingKey,
-				false,
-				false,
-				amqp.Publishing{
-					Timestamp: time.Now(),
-					Body:      msg.Body,
-				},
-			)
-			if err != nil {
-				if msg.Retries < r.maxRetries {
-					r.logger.Warningf("try to send message again (%v)...", msg)
-					r.sendMsg <- internalMsg{
-						Exchange: msg.Exchange,
-						Body:     msg.Body,
-						Retries:  msg.Retries + 1,
-					}
-				}
-				return fmt.Errorf("failed to publish message to rabbitmq (exchange=%s, routingKey=%s) - %v", msg.Exchange.Exchange, msg.Exchange.RoutingKey, err)
+			r.notifyState(RabbitMqStateEvent{State: RABBITMQ_UNSUBSCRIBED, Exchange: sub.exchange})
+		}
+	}
+}
+
+func (r *RabbitMqMessenger) connectionUri() (amqp.URI, error) {
+	// Use given uri or build one from host, port and credentials. Building the
+	// uri from its parts makes sure, that credentials and vhost get escaped.
+	var uri amqp.URI
+	if r.uri != "" {
+		parsed, err := amqp.ParseURI(r.uri)
+		if err != nil {
+			return amqp.URI{}, fmt.Errorf("invalid rabbitmq uri - %v", err)
+		}
+		uri = parsed
+		// TLS options upgrade amqp uris, the default port is switched unless given explicitly
+		if r.useTls() && uri.Scheme == "amqp" {
+			uri.Scheme = "amqps"
+			if u, err := url.Parse(r.uri); err == nil && u.Port() == "" {
+				uri.Port = 5671
 			}
-		// Case 3: Add subscription
-		case 3:
-			req, ok := value.Interface().(subsciptionReq)
-			if !ok {
-				r.logger.Warning("failed to cast message for subsciption request")
+		}
+	} else {
+		uri = amqp.URI{
+			Scheme:   "amqp",
+			Host:     r.host,
+			Port:     int(r.port),
+			Username: r.user,
+			Password: r.password,
+			Vhost:    "/",
+		}
+		if r.useTls() {
+			uri.Scheme = "amqps"
+		}
+	}
+	if r.vhost != "" {
+		uri.Vhost = r.vhost
+	}
+	return uri, nil
+}
+
+func (r *RabbitMqMessenger) dial(uri amqp.URI) (*amqp.Connection, error) {
+	config := amqp.Config{
+		Vhost:      uri.Vhost,
+		Heartbeat:  r.heartbeat,
+		Properties: amqp.NewConnectionProperties(),
+	}
+	if r.connectionName != "" {
+		config.Properties.SetClientConnectionName(r.connectionName)
+	}
+	if uri.Scheme == "amqps" {
+		tlsConfig, err := r.createTlsConfig(uri)
+		if err != nil {
+			return nil, err
+		}
+		config.TLSClientConfig = tlsConfig
+	}
+	return amqp.DialConfig(uri.String(), config)
+}
+
+func (r *RabbitMqMessenger) useTls() bool {
+	return r.tlsConfig != nil || r.tlsCaFile != "" || r.tlsCertFile != "" || r.tlsKeyFile != "" || r.tlsServerName != ""
+}
+
+func (r *RabbitMqMessenger) createTlsConfig(uri amqp.URI) (*tls.Config, error) {
+	// Start from given config, if any
+	config := &tls.Config{}
+	if r.tlsConfig != nil {
+		config = r.tlsConfig.Clone()
+	}
+	// Server name
+	config.ServerName = cmp.Or(r.tlsServerName, config.ServerName, uri.ServerName, uri.Host)
+	// Root CA
+	if caFile := cmp.Or(r.tlsCaFile, uri.CACertFile); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read rabbitmq ca file - %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("failed to parse rabbitmq ca file %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	// Client certificate
+	certFile := cmp.Or(r.tlsCertFile, uri.CertFile)
+	keyFile := cmp.Or(r.tlsKeyFile, uri.KeyFile)
+	if certFile != "" && keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rabbitmq client certificate - %v", err)
+		}
+		config.Certificates = append(config.Certificates, cert)
+	} else if certFile != "" || keyFile != "" {
+		return nil, fmt.Errorf("rabbitmq client certificate requires both cert and key file")
+	}
+	return config, nil
+}
+
+func (r *RabbitMqMessenger) publish(ch *amqp.Channel, declarer *exchangeDeclarer, stop <-chan struct{}) error {
+	for {
+		select {
+		case <-stop:
+			return nil
+		case msg := <-r.sendMsg:
+			err := r.publishMsg(ch, declarer, msg)
+			if err == nil {
 				continue
 			}
-			// Add subscribtion
-			r.subscriptions[req.sub] = subsciption{
-				exchange: req.exchange,
-				queue:    nil,
-				consumer: nil,
+			// Retry single message, failed messages count as retry, even if the channel is gone
+			if msg.Retries >= r.maxRetries {
+				r.deadLetter(msg.Exchange, msg.Body, err)
+			} else {
+				delay := r.publishBackoff.Next(msg.Retries)
+				r.logger.Warningf("failed to publish message to rabbitmq (exchange=%s, routingKey=%s), retry %d/%d in %v - %v", msg.Exchange.Exchange, msg.Exchange.RoutingKey, msg.Retries+1, r.maxRetries, delay, err)
+				r.enqueue(internalMsg{
+					Exchange: msg.Exchange,
+					Body:     msg.Body,
+					Headers:  msg.Headers,
+					Retries:  msg.Retries + 1,
+				}, delay)
 			}
-			// Bind
-			r.declareAndBindQueueForSub(ch, req.sub)
-		// Case 4: Remove subsciption
-		case 4:
-			msg, ok := value.Interface().(async.Stream[amqp.Delivery])
-			if !ok {
-				r.logger.Warning("failed to cast message for sending to rabbitmq")
-				continue
+			// Channel is gone, continue on the next connection
+			if ch.IsClosed() {
+				return fmt.Errorf("failed to publish message to rabbitmq (exchange=%s, routingKey=%s) - %v", msg.Exchange.Exchange, msg.Exchange.RoutingKey, err)
 			}
-			sub := r.subscriptions[msg]
-			ch.QueueDelete(sub.queue.Name, false, false, true)
-			delete(r.subscriptions, msg)
-		// Case 5-n: Message for subsciption received
-		default:
-			msg, ok := value.Interface().(amqp.Delivery)
-			if !ok {
-				r.logger.Warning("failed to cast message for sending to rabbitmq")
-				continue
+		}
+	}
+}
+
+func (r *RabbitMqMessenger) publishMsg(ch *amqp.Channel, declarer *exchangeDeclarer, msg internalMsg) error {
+	if err := declarer.declare(msg.Exchange); err != nil {
+		return err
+	}
+	return ch.Publish(
+		msg.Exchange.Exchange,
+		msg.Exchange.RoutingKey,
+		false,
+		false,
+		amqp.Publishing{
+			Timestamp: time.Now(),
+			Headers:   msg.Headers,
+			Body:      msg.Body,
+		},
+	)
+}
+
+// enqueue puts the message back into the send queue after the given delay without
+// blocking the publisher
+func (r *RabbitMqMessenger) enqueue(msg internalMsg, delay time.Duration) {
+	go func() {
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-timer.C:
 			}
-			for k, sub := range r.subscriptions {
-				if sub.caseIdx == idx {
-					k <- async.ActionResult[amqp.Delivery]{
-						Result: msg,
-						Error:  nil,
-					}
+		}
+		select {
+		case <-r.ctx.Done():
+		case r.sendMsg <- msg:
+		}
+	}()
+}
+
+// consume forwards deliveries to the stream until stopped. It returns false, if the
+// consumer was closed by the broker (e.g. the channel of the subscription failed).
+func (r *RabbitMqMessenger) consume(stream async.Stream[amqp.Delivery], consumer <-chan amqp.Delivery, stop <-chan struct{}) bool {
+	for {
+		select {
+		case <-stop:
+			return true
+		case msg, ok := <-consumer:
+			if !ok {
+				select {
+				case <-stop:
+					return true
+				default:
+					return false
 				}
 			}
+			select {
+			case stream <- async.ActionResult[amqp.Delivery]{Result: msg, Error: nil}:
+			case <-stop:
+				return true
+			}
 		}
 	}
 }
 
-func (r *RabbitMqMessenger) initCurrentSubscriptions(ch *amqp.Channel) error {
-	for k := range r.subscriptions {
-		if err := r.declareAndBindQueueForSub(ch, k); err != nil {
-			return err
+// initCurrentSubscriptions starts all registered subscriptions. Failed subscriptions are
+// reported as SUBSCRIPTION_FAILED and retried on the next reconnect, the others keep running.
+func (r *RabbitMqMessenger) initCurrentSubscriptions(conn *amqp.Connection, declarer *exchangeDeclarer, workers *sync.WaitGroup) {
+	for k, sub := range r.subscriptions {
+		if err := r.declareAndBindQueueForSub(conn, declarer, k, workers); err != nil {
+			r.logger.Warningf("failed to subscribe to rabbitmq (exchange=%s, routingKey=%s) - %v", sub.exchange.Exchange, sub.exchange.RoutingKey, err)
 		}
 	}
-	return nil
 }
 
-func (r *RabbitMqMessenger) declareAndBindQueueForSub(ch *amqp.Channel, key async.Stream[amqp.Delivery]) error {
+func (r *RabbitMqMessenger) stopSubscriptions() {
+	for _, sub := range r.subscriptions {
+		if sub.stop != nil {
+			close(sub.stop)
+			sub.stop = nil
+			sub.ch.Close()
+		}
+	}
+}
+
+func (r *RabbitMqMessenger) declareAndBindQueueForSub(conn *amqp.Connection, declarer *exchangeDeclarer, key async.Stream[amqp.Delivery], workers *sync.WaitGroup) error {
 	sub, exists := r.subscriptions[key]
 	if !exists {
 		return fmt.Errorf("no subscibtion for key registerd")
 	}
+	if err := r.startConsumer(conn, declarer, key, sub, workers); err != nil {
+		r.notifyState(RabbitMqStateEvent{State: RABBITMQ_SUBSCRIPTION_FAILED, Exchange: sub.exchange, Cause: err})
+		return err
+	}
+	r.notifyState(RabbitMqStateEvent{State: RABBITMQ_SUBSCRIBED, Exchange: sub.exchange})
+	return nil
+}
+
+func (r *RabbitMqMessenger) startConsumer(conn *amqp.Connection, declarer *exchangeDeclarer, key async.Stream[amqp.Delivery], sub *subsciption, workers *sync.WaitGroup) (err error) {
 	// Declare exchange, if not exists
-	err := ch.ExchangeDeclare(
-		sub.exchange.Exchange, // name
-		sub.exchange.Type,     // type
-		false,                 // durable
-		true,                  // auto-deleted
-		false,                 // internal
-		false,                 // no-wait
-		nil,                   // arguments
-	)
+	if err := declarer.declare(sub.exchange); err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err != nil {
+			ch.Close()
+		}
+	}()
+	chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
 	// Declare queue for subscibtion
 	q, err := ch.QueueDeclare(
 		"",
@@ -266,9 +531,10 @@ func (r *RabbitMqMessenger) declareAndBindQueueForSub(ch *amqp.Channel, key asyn
 		return err
 	}
 	// Create consumer
+	consumerTag := fmt.Sprintf("go-common-%s", q.Name)
 	consumer, err := ch.Consume(
 		q.Name,
-		"",
+		consumerTag,
 		true,  // Auto-Ack
 		true,  // Exclusive
 		false, // NoLocal
@@ -278,57 +544,104 @@ func (r *RabbitMqMessenger) declareAndBindQueueForSub(ch *amqp.Channel, key asyn
 	if err != nil {
 		return err
 	}
-	// Update subscription
-	r.subscriptions[key] = subsciption{
-		exchange: sub.exchange,
-		queue:    &q,
-		consumer: consumer,
-	}
+	// Update subscription and start dedicated worker
+	sub.ch = ch
+	sub.queue = q.Name
+	sub.stop = make(chan struct{})
+	workers.Add(1)
+	go func(exchange RabbitMqExchange, stop <-chan struct{}) {
+		defer workers.Done()
+		// Report subscriptions failing on their own, a lost connection is reported as DISCONNECTED
+		if r.consume(key, consumer, stop) || conn.IsClosed() {
+			return
+		}
+		cause := fmt.Errorf("rabbitmq consumer cancelled")
+		select {
+		case err := <-chClosed:
+			cause = channelClosedError(err)
+		default:
+		}
+		r.notifyState(RabbitMqStateEvent{State: RABBITMQ_SUBSCRIPTION_FAILED, Exchange: exchange, Cause: cause})
+	}(sub.exchange, sub.stop)
 	return nil
 }
 
-func (r *RabbitMqMessenger) createSelectCases(connClosed chan *amqp.Error) []reflect.SelectCase {
-	// Create collection
-	c := []reflect.SelectCase{}
-	// Case 0: Parent context done
-	c = append(c, reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(r.ctx.Done()),
-	})
-	// Case 1: Channel closed
-	c = append(c, reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(connClosed),
-	})
-	// Case 2: Send message
-	c = append(c, reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(r.sendMsg),
-	})
-	// Case 3: Add subscription
-	c = append(c, reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(r.addSub),
-	})
-	// Case 4: Remove subsciption
-	c = append(c, reflect.SelectCase{
-		Dir:  reflect.SelectRecv,
-		Chan: reflect.ValueOf(r.removeSub),
-	})
-	// Case 5-n: Message for subsciption received
-	for k, v := range r.subscriptions {
-		c = append(c, reflect.SelectCase{
-			Dir:  reflect.SelectRecv,
-			Chan: reflect.ValueOf(v.consumer),
-		})
-		r.subscriptions[k] = subsciption{
-			caseIdx:  len(c) - 1,
-			exchange: v.exchange,
-			queue:    v.queue,
-			consumer: v.consumer,
+func (r *RabbitMqMessenger) setState(state RabbitMqState, cause error) {
+	r.stateMux.Lock()
+	changed := r.state != state || r.stateCause != cause
+	r.state = state
+	r.stateCause = cause
+	r.stateMux.Unlock()
+	if changed {
+		r.notifyState(RabbitMqStateEvent{State: state, Cause: cause})
+	}
+}
+
+func (r *RabbitMqMessenger) notifyState(event RabbitMqStateEvent) {
+	event.Time = time.Now()
+	r.stateMux.RLock()
+	defer r.stateMux.RUnlock()
+	for observer := range r.stateObservers {
+		// Never block the connection loop on slow observers
+		select {
+		case observer <- async.ActionResult[RabbitMqStateEvent]{Result: event, Error: nil}:
+		default:
+			r.logger.Warningf("dropped rabbitmq state event %s, observer buffer full", event.State)
 		}
 	}
-	return c
+}
+
+func (d *exchangeDeclarer) declare(exchange RabbitMqExchange) error {
+	// The default exchange can not be declared
+	if exchange.Exchange == "" {
+		return nil
+	}
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	if d.declared[exchange.Exchange] {
+		return nil
+	}
+	ch, err := d.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	declare := ch.ExchangeDeclare
+	if exchange.Passive {
+		declare = ch.ExchangeDeclarePassive
+	}
+	err = declare(
+		exchange.Exchange,   // name
+		exchange.Type,       // type
+		exchange.Durable,    // durable
+		exchange.AutoDelete, // auto-deleted
+		exchange.Internal,   // internal
+		false,               // no-wait
+		exchange.Arguments,  // arguments
+	)
+	var amqpErr *amqp.Error
+	if exchange.Passive && errors.As(err, &amqpErr) && amqpErr.Code == amqp.NotFound {
+		return fmt.Errorf("rabbitmq exchange %s does not exist", exchange.Exchange)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to declare rabbitmq exchange %s - %w", exchange.Exchange, err)
+	}
+	d.declared[exchange.Exchange] = true
+	return nil
+}
+
+func newExchangeDeclarer(conn *amqp.Connection) *exchangeDeclarer {
+	return &exchangeDeclarer{
+		conn:     conn,
+		declared: map[string]bool{},
+	}
+}
+
+func channelClosedError(err *amqp.Error) error {
+	if err == nil {
+		return fmt.Errorf("rabbitmq channel closed")
+	}
+	return err
 }
 
 // -----------------------------------------------------------------------------------
@@ -341,18 +654,101 @@ func WithRabbitMqSerializer(serializer serialization.ISerializer) func(*RabbitMq
 	}
 }
 
+// WithRabbitMqRetryInterval reconnects with a fixed interval instead of exponential backoff
 func WithRabbitMqRetryInterval(interval time.Duration) func(*RabbitMqMessenger) {
 	return func(rmm *RabbitMqMessenger) {
-		rmm.retryInterval = interval
+		rmm.reconnectBackoff = NewConstantBackoff(interval)
+	}
+}
+
+func WithRabbitMqReconnectBackoff(backoff IBackoff) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.reconnectBackoff = backoff
 	}
 }
 
+// WithRabbitMqMaxRetries sets how often publishing a single message is retried
+// before it is handed to the dead letter handler
 func WithRabbitMqMaxRetries(retries uint) func(*RabbitMqMessenger) {
 	return func(rmm *RabbitMqMessenger) {
 		rmm.maxRetries = retries
 	}
 }
 
+func WithRabbitMqPublishBackoff(backoff IBackoff) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.publishBackoff = backoff
+	}
+}
+
+// WithRabbitMqDeadLetterHandler sets a callback for messages, that could not be
+// published within maxRetries (default: log an error)
+func WithRabbitMqDeadLetterHandler(handler func(topic RabbitMqExchange, body []byte, err error)) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.deadLetter = handler
+	}
+}
+
+// WithRabbitMqUri sets a full AMQP URI (amqp:// or amqps://). If set, host, port, user
+// and password given to the constructor are ignored. Combined with TLS options, amqp://
+// uris are connected via amqps (port 5671, if the uri has no port).
+func WithRabbitMqUri(uri string) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.uri = uri
+	}
+}
+
+func WithRabbitMqVhost(vhost string) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.vhost = vhost
+	}
+}
+
+// WithRabbitMqTlsConfig enables TLS (amqps) using the given config as base. CA, client
+// certificate and server name options are applied on top of it.
+func WithRabbitMqTlsConfig(config *tls.Config) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.tlsConfig = config
+	}
+}
+
+// WithRabbitMqTlsCa enables TLS (amqps) and verifies the server against the CA
+// certificates in the given PEM file.
+func WithRabbitMqTlsCa(caFile string) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.tlsCaFile = caFile
+	}
+}
+
+// WithRabbitMqTlsClientCert enables TLS (amqps) and authenticates with the given
+// PEM encoded client certificate and key.
+func WithRabbitMqTlsClientCert(certFile, keyFile string) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.tlsCertFile = certFile
+		rmm.tlsKeyFile = keyFile
+	}
+}
+
+func WithRabbitMqTlsServerName(serverName string) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.tlsServerName = serverName
+	}
+}
+
+func WithRabbitMqHeartbeat(interval time.Duration) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.heartbeat = interval
+	}
+}
+
+// WithRabbitMqConnectionName sets the client provided connection name shown in the
+// rabbitmq management ui.
+func WithRabbitMqConnectionName(name string) func(*RabbitMqMessenger) {
+	return func(rmm *RabbitMqMessenger) {
+		rmm.connectionName = name
+	}
+}
+
 func WithRabbitMqStreamBufferSize(size uint) func(*RabbitMqMessenger) {
 	return func(rmm *RabbitMqMessenger) {
 		rmm.streamBuffer = size
@@ -363,7 +759,7 @@ func WithRabbitMqStreamBufferSize(size uint) func(*RabbitMqMessenger) {
 // Constructor
 // -----------------------------------------------------------------------------------
 
-func NewRabbitMqMessenger(ctx context.Context, logger log.ILogger, host string, port uint16, user string, password string, opts ...func(*RabbitMqMessenger)) IMessenger[RabbitMqExchange, amqp.Delivery] {
+func NewRabbitMqMessenger(ctx context.Context, logger log.ILogger, host string, port uint16, user string, password string, opts ...func(*RabbitMqMessenger)) IRabbitMqMessenger {
 	// Init new RabbitMqMessenger
 	m := &RabbitMqMessenger{
 		ctx:      ctx,
@@ -373,15 +769,23 @@ func NewRabbitMqMessenger(ctx context.Context, logger log.ILogger, host string,
 		user:     user,
 		password: password,
 
-		retryInterval: 10 * time.Second,
-		maxRetries:    10,
-		streamBuffer:  50,
-		serializer:    serialization.NewJSONSerializer(),
+		maxRetries:       10,
+		reconnectBackoff: NewExponentialBackoff(time.Second, 30*time.Second),
+		publishBackoff:   NewExponentialBackoff(100*time.Millisecond, 10*time.Second),
+		streamBuffer:     50,
+		serializer:       serialization.NewJSONSerializer(),
 
-		subscriptions: map[async.Stream[amqp.Delivery]]subsciption{},
-		sendMsg:       make(chan internalMsg, 50),
-		addSub:        make(chan subsciptionReq, 50),
-		removeSub:     make(chan async.Stream[amqp.Delivery], 50),
+		subscriptions: map[async.Stream[amqp.Delivery]]*subsciption{},
+
+		state:          RABBITMQ_CONNECTING,
+		stateObservers: map[async.Stream[RabbitMqStateEvent]]bool{},
+
+		sendMsg:   make(chan internalMsg, 50),
+		addSub:    make(chan subsciptionReq, 50),
+		removeSub: make(chan async.Stream[amqp.Delivery], 50),
+	}
+	m.deadLetter = func(topic RabbitMqExchange, body []byte, err error) {
+		m.logger.Errorf("dropped message for rabbitmq (exchange=%s, routingKey=%s) after %d retries - %v", topic.Exchange, topic.RoutingKey, m.maxRetries, err)
 	}
 	// Apply options
 	for _, o := range opts {
@@ -389,16 +793,27 @@ func NewRabbitMqMessenger(ctx context.Context, logger log.ILogger, host string,
 	}
 	// Run Messenger
 	go func() {
+		attempt := uint(0)
 		for {
+			connected, err := m.run()
+			if m.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				m.logger.Error(err.Error())
+			}
+			// Start backoff from scratch, if last connection was established
+			if connected {
+				attempt = 0
+			}
+			delay := m.reconnectBackoff.Next(attempt)
+			attempt++
+			timer := time.NewTimer(delay)
 			select {
 			case <-m.ctx.Done():
+				timer.Stop()
 				return
-			default:
-				err := m.run()
-				if err != nil {
-					m.logger.Error(err.Error())
-					time.Sleep(m.retryInterval)
-				}
+			case <-timer.C:
 			}
 		}
 	}()
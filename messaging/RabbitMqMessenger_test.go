@@ -0,0 +1,160 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uoul/go-common/async"
+	"github.com/uoul/go-common/log"
+	"github.com/uoul/go-common/serialization"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// -----------------------------------------------------------------------------------
+// Tests
+// -----------------------------------------------------------------------------------
+
+func TestConsumeForwardsDeliveries(t *testing.T) {
+	r := newTestMessenger()
+	stream := async.NewBufferedStream[amqp.Delivery](10)
+	deliveries := make(chan amqp.Delivery, 10)
+	stop := make(chan struct{})
+	done := make(chan bool)
+	go func() { done <- r.consume(stream, deliveries, stop) }()
+
+	deliveries <- amqp.Delivery{Body: []byte("hello")}
+	msg := <-stream
+	if msg.Error != nil || string(msg.Result.Body) != "hello" {
+		t.Fatalf("unexpected delivery %q (err=%v)", msg.Result.Body, msg.Error)
+	}
+	close(stop)
+	if stopped := <-done; !stopped {
+		t.Fatal("expected consume to report a regular stop")
+	}
+}
+
+func TestConsumeReportsClosedConsumer(t *testing.T) {
+	r := newTestMessenger()
+	deliveries := make(chan amqp.Delivery)
+	close(deliveries)
+	if r.consume(async.NewStream[amqp.Delivery](), deliveries, make(chan struct{})) {
+		t.Fatal("expected consume to report a consumer closed by the broker")
+	}
+}
+
+func TestConsumeStopsOnBlockedStream(t *testing.T) {
+	r := newTestMessenger()
+	deliveries := make(chan amqp.Delivery, 1)
+	deliveries <- amqp.Delivery{}
+	stop := make(chan struct{})
+	done := make(chan bool)
+	// Nobody reads the unbuffered stream, stop must still end the worker
+	go func() { done <- r.consume(async.NewStream[amqp.Delivery](), deliveries, stop) }()
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("consume did not stop while blocked on the stream")
+	}
+}
+
+func TestPublishContextCancelledWhileDisconnected(t *testing.T) {
+	r := newTestMessenger()
+	// Nobody reads the send buffer while disconnected
+	r.sendMsg = make(chan internalMsg)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan error)
+	go func() { done <- r.PublishContext(ctx, RabbitMqExchange{Exchange: "test"}, "hello") }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked despite cancelled context")
+	}
+}
+
+func TestPublishContextClosedMessenger(t *testing.T) {
+	r := newTestMessenger()
+	r.sendMsg = make(chan internalMsg)
+	ctx, cancel := context.WithCancel(context.Background())
+	r.ctx = ctx
+	cancel()
+	if err := r.Publish(RabbitMqExchange{Exchange: "test"}, "hello"); err == nil {
+		t.Fatal("expected publish on closed messenger to fail")
+	}
+}
+
+// -----------------------------------------------------------------------------------
+// Benchmarks
+// -----------------------------------------------------------------------------------
+
+// BenchmarkConsumeFanOut measures the throughput of the per-subscription workers, fed by
+// fake delivery sources instead of a broker
+func BenchmarkConsumeFanOut(b *testing.B) {
+	for _, subs := range []int{1, 10, 100, 500, 1000} {
+		b.Run(fmt.Sprintf("subscriptions=%d", subs), func(b *testing.B) {
+			benchmarkConsumeFanOut(b, subs)
+		})
+	}
+}
+
+// -----------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------
+
+func benchmarkConsumeFanOut(b *testing.B, subs int) {
+	r := newTestMessenger()
+	stop := make(chan struct{})
+	workers := &sync.WaitGroup{}
+	received := &sync.WaitGroup{}
+	sources := make([]chan amqp.Delivery, subs)
+	for i := range sources {
+		sources[i] = make(chan amqp.Delivery, r.streamBuffer)
+		stream := async.NewBufferedStream[amqp.Delivery](r.streamBuffer)
+		workers.Add(2)
+		go func(source <-chan amqp.Delivery) {
+			defer workers.Done()
+			r.consume(stream, source, stop)
+		}(sources[i])
+		// Subscriber draining its stream
+		go func() {
+			defer workers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-stream:
+					received.Done()
+				}
+			}
+		}()
+	}
+	delivery := amqp.Delivery{Body: []byte(`{"id":1,"name":"benchmark"}`)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	received.Add(b.N)
+	for i := 0; i < b.N; i++ {
+		sources[i%subs] <- delivery
+	}
+	received.Wait()
+	b.StopTimer()
+	close(stop)
+	workers.Wait()
+}
+
+func newTestMessenger() *RabbitMqMessenger {
+	return &RabbitMqMessenger{
+		ctx:          context.Background(),
+		logger:       log.NewConsoleLogger(log.ERROR),
+		streamBuffer: 50,
+		serializer:   serialization.NewJSONSerializer(),
+	}
+}
@@ -0,0 +1,43 @@
+package messaging
+
+import "time"
+
+type RabbitMqState int
+
+const (
+	RABBITMQ_CONNECTING          RabbitMqState = iota
+	RABBITMQ_CONNECTED           RabbitMqState = iota
+	RABBITMQ_DISCONNECTED        RabbitMqState = iota
+	RABBITMQ_SUBSCRIBED          RabbitMqState = iota
+	RABBITMQ_SUBSCRIPTION_FAILED RabbitMqState = iota
+	RABBITMQ_UNSUBSCRIBED        RabbitMqState = iota
+)
+
+func (s RabbitMqState) String() string {
+	switch s {
+	case RABBITMQ_CONNECTING:
+		return "CONNECTING"
+	case RABBITMQ_CONNECTED:
+		return "CONNECTED"
+	case RABBITMQ_DISCONNECTED:
+		return "DISCONNECTED"
+	case RABBITMQ_SUBSCRIBED:
+		return "SUBSCRIBED"
+	case RABBITMQ_SUBSCRIPTION_FAILED:
+		return "SUBSCRIPTION_FAILED"
+	case RABBITMQ_UNSUBSCRIBED:
+		return "UNSUBSCRIBED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// RabbitMqStateEvent describes a change of the connection or of a single subscription.
+// Exchange is only set for subscription events, Cause is set for DISCONNECTED and
+// SUBSCRIPTION_FAILED.
+type RabbitMqStateEvent struct {
+	State    RabbitMqState
+	Exchange RabbitMqExchange
+	Cause    error
+	Time     time.Time
+}
@@ -0,0 +1,56 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/uoul/go-common/tracing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// -----------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------
+
+const (
+	REQUEST_ID_MSG_HEADER  = "x-request-id"
+	TRACEPARENT_MSG_HEADER = "traceparent"
+)
+
+// -----------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------
+
+// DeliveryContext returns a context carrying request id and trace context of a message
+// published with PublishContext, so that consumers continue the trace
+func DeliveryContext(ctx context.Context, delivery amqp.Delivery) context.Context {
+	if requestId, ok := delivery.Headers[REQUEST_ID_MSG_HEADER].(string); ok && requestId != "" {
+		ctx = tracing.WithRequestId(ctx, requestId)
+	}
+	if value, ok := delivery.Headers[TRACEPARENT_MSG_HEADER].(string); ok {
+		if traceParent, err := tracing.ParseTraceParent(value); err == nil {
+			ctx = tracing.WithTraceParent(ctx, traceParent.Child())
+		}
+	}
+	return ctx
+}
+
+// -----------------------------------------------------------------------------------
+// Private Functions
+// -----------------------------------------------------------------------------------
+
+// tracingHeaders returns the message headers propagating request id and trace context
+// (nil if the context carries neither)
+func tracingHeaders(ctx context.Context) amqp.Table {
+	headers := amqp.Table{}
+	if requestId := tracing.RequestId(ctx); requestId != "" {
+		headers[REQUEST_ID_MSG_HEADER] = requestId
+	}
+	if traceParent, ok := tracing.GetTraceParent(ctx); ok {
+		headers[TRACEPARENT_MSG_HEADER] = traceParent.Child().String()
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
@@ -0,0 +1,30 @@
+package servemux
+
+import (
+	"time"
+
+	"github.com/uoul/go-common/log"
+)
+
+// AccessLog returns a middleware, that logs method, path, status, response size, duration
+// and remote address of each request. Register it before Recovery, so that recovered
+// panics are logged as 500. Request id and trace id are logged, if RequestTracing runs
+// in the chain.
+func AccessLog(logger log.ILogger) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		start := time.Now()
+		recorder := NewResponseRecorder(ctx.GetRawResponseWriter())
+		ctx.SetRawResponseWriter(recorder)
+		ctx.Next()
+		req := ctx.GetRawRequest()
+		log.WithContext(logger, ctx.Context()).Infof(
+			"%s %s %d %dB %v %s",
+			req.Method,
+			req.URL.RequestURI(),
+			recorder.Status(),
+			recorder.Size(),
+			time.Since(start),
+			req.RemoteAddr,
+		)
+	}
+}
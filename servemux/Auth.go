@@ -0,0 +1,114 @@
+package servemux
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/uoul/go-common/auth"
+)
+
+// identityKey stores the identity of type I set by Authenticate
+var identityKey = NewKey[any]("identity")
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// Authenticate returns a middleware, that authenticates the request using the given
+// authenticator and stores the identity on the context (see GetIdentity). Invalid
+// credentials abort the request with 401 and a WWW-Authenticate header for the given
+// realm. Errors wrapping auth.ErrUnavailable abort with 503, their details are not exposed.
+func Authenticate[I any](authenticator auth.IAuthenticator[I], realm string) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		header := ctx.GetRawRequest().Header
+		if header.Get(auth.AUTH_HEADER) == "" {
+			abortUnauthorized(ctx, realm, "", NewHttpError(http.StatusUnauthorized, "missing authorization header"))
+			return
+		}
+		identity, err := authenticator.GetIdentityFromAuthorizationHeader(header)
+		if errors.Is(err, auth.ErrUnavailable) {
+			ctx.SetStatusCode(http.StatusServiceUnavailable)
+			ctx.AbortWithError(err)
+			return
+		}
+		if err != nil {
+			abortUnauthorized(ctx, realm, "invalid_token", NewHttpError(http.StatusUnauthorized, "invalid credentials"))
+			return
+		}
+		SetValue(ctx, identityKey, any(identity))
+	}
+}
+
+// GetIdentity returns the identity stored by Authenticate, if it is of type I
+func GetIdentity[I any, T any](ctx *HttpCtx[T]) (I, bool) {
+	value, _ := GetValue(ctx, identityKey)
+	identity, ok := value.(I)
+	return identity, ok
+}
+
+// Authorize returns a middleware, that aborts with 401 if no identity of type I is stored
+// on the context and with 403 if the check fails
+func Authorize[I any](check func(identity I) bool) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		identity, ok := GetIdentity[I](ctx)
+		if !ok {
+			ctx.AbortWithError(NewHttpError(http.StatusUnauthorized, "not authenticated"))
+			return
+		}
+		if !check(identity) {
+			ctx.AbortWithError(NewHttpError(http.StatusForbidden, "access denied"))
+		}
+	}
+}
+
+// RequireRoles returns a middleware, that requires the identity to have at least one of
+// the given roles
+func RequireRoles[I any](rolesOf func(identity I) []string, roles ...string) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		identity, ok := GetIdentity[I](ctx)
+		if !ok {
+			ctx.AbortWithError(NewHttpError(http.StatusUnauthorized, "not authenticated"))
+			return
+		}
+		granted := rolesOf(identity)
+		if !slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(granted, role) }) {
+			ctx.AbortWithError(NewHttpError(http.StatusForbidden, "one of roles [%s] required", strings.Join(roles, ", ")))
+		}
+	}
+}
+
+// RequireScopes returns a middleware, that requires the identity to have all given scopes.
+// Missing scopes are reported with error "insufficient_scope" in the WWW-Authenticate header.
+func RequireScopes[I any](scopesOf func(identity I) []string, realm string, scopes ...string) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		identity, ok := GetIdentity[I](ctx)
+		if !ok {
+			ctx.AbortWithError(NewHttpError(http.StatusUnauthorized, "not authenticated"))
+			return
+		}
+		granted := scopesOf(identity)
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				ctx.SetHeader("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q, error="insufficient_scope", scope=%q`, realm, strings.Join(scopes, " ")))
+				ctx.AbortWithError(NewHttpError(http.StatusForbidden, "scope %s required", scope))
+				return
+			}
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private Functions
+// -----------------------------------------------------------------------------------------------------------
+
+func abortUnauthorized(ctx *HttpCtx[any], realm string, errorCode string, err error) {
+	challenge := fmt.Sprintf("Bearer realm=%q", realm)
+	if errorCode != "" {
+		challenge += fmt.Sprintf(", error=%q", errorCode)
+	}
+	ctx.SetHeader("WWW-Authenticate", challenge)
+	ctx.AbortWithError(err)
+}
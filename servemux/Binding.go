@@ -0,0 +1,203 @@
+package servemux
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoul/go-common/validation"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// BindingError lists all parameters, that could not be bound by HttpCtx.BindParams
+type BindingError struct {
+	Errors []validation.FieldError
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// Error implements IHttpError.
+func (e *BindingError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = fmt.Sprintf("%s: %s", err.Field, err.Reason)
+	}
+	return fmt.Sprintf("invalid parameters - %s", strings.Join(msgs, "; "))
+}
+
+// StatusCode implements IHttpError.
+func (e *BindingError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// BindParams fills the struct pointed to by target from path values, query string and
+// headers according to the field tags `path:"id"`, `query:"page"` and `header:"X-Tenant"`.
+// A `default:"..."` tag is used if the parameter is missing. Supported are strings, bools,
+// numbers, time.Time (RFC 3339), time.Duration, encoding.TextUnmarshaler (e.g. UUIDs),
+// pointers (optional parameters) and slices (repeated or comma separated values).
+//
+// All conversion errors are collected into a BindingError. If validation is enabled, the
+// bound struct is validated too. Like GetBody, the error is not recorded on the context,
+// pass it to AbortWithError to answer with a 400 problem response.
+func (h *HttpCtx[T]) BindParams(target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind target must be a pointer to a struct, got %T", target)
+	}
+	fieldErrors := []validation.FieldError{}
+	h.bindStruct(v.Elem(), &fieldErrors)
+	if len(fieldErrors) > 0 {
+		return &BindingError{Errors: fieldErrors}
+	}
+	if h.validate {
+		if err := validation.Validate(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (h *HttpCtx[T]) bindStruct(v reflect.Value, fieldErrors *[]validation.FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		// Bind embedded structs without tags recursively
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag == "" {
+			h.bindStruct(v.Field(i), fieldErrors)
+			continue
+		}
+		source, name, values := h.lookupParam(field)
+		if source == "" {
+			continue
+		}
+		if len(values) == 0 {
+			defaultValue, exists := field.Tag.Lookup("default")
+			if !exists {
+				continue
+			}
+			values = []string{defaultValue}
+		}
+		if err := setParam(v.Field(i), values); err != nil {
+			*fieldErrors = append(*fieldErrors, validation.FieldError{
+				Field:  fmt.Sprintf("%s.%s", source, name),
+				Reason: err.Error(),
+			})
+		}
+	}
+}
+
+// lookupParam returns source, name and raw values of the parameter bound to the field
+func (h *HttpCtx[T]) lookupParam(field reflect.StructField) (string, string, []string) {
+	if name, exists := field.Tag.Lookup("path"); exists {
+		if value := h.req.PathValue(name); value != "" {
+			return "path", name, []string{value}
+		}
+		return "path", name, nil
+	}
+	if name, exists := field.Tag.Lookup("query"); exists {
+		return "query", name, h.req.URL.Query()[name]
+	}
+	if name, exists := field.Tag.Lookup("header"); exists {
+		return "header", name, h.req.Header.Values(name)
+	}
+	return "", "", nil
+}
+
+func setParam(v reflect.Value, values []string) error {
+	// Pointer: allocate and set value pointed to
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setParam(ptr.Elem(), values); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	// Slice: repeated parameters or comma separated values
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		items := []string{}
+		for _, value := range values {
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), item); err != nil {
+				return fmt.Errorf("element %d %v", i, err)
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, values[0])
+}
+
+func setValue(v reflect.Value, raw string) error {
+	// Types with custom text representation (e.g. uuid.UUID, time.Time)
+	if v.CanAddr() {
+		if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := unmarshaler.UnmarshalText([]byte(raw)); err != nil {
+				return fmt.Errorf("invalid value %q - %v", raw, err)
+			}
+			return nil
+		}
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package servemux
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type CompressionConfig struct {
+	level         int
+	minSize       int
+	excludedTypes []string
+}
+
+// compressWriter buffers the beginning of the response, until it is known whether the
+// response is worth compressing (size, content type), and compresses it afterwards
+type compressWriter struct {
+	http.ResponseWriter
+	config   *CompressionConfig
+	encoding string
+	method   string
+
+	status     int
+	buffer     []byte
+	decided    bool
+	hijacked   bool
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// WriteHeader implements http.ResponseWriter.
+func (cw *compressWriter) WriteHeader(statusCode int) {
+	// Informational responses are passed through
+	if statusCode < http.StatusOK {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if cw.status == 0 {
+		cw.status = statusCode
+	}
+}
+
+// Write implements http.ResponseWriter.
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		cw.buffer = append(cw.buffer, b...)
+		if len(cw.buffer) < cw.config.minSize {
+			return len(b), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher. Flushing starts the response regardless of its size, so
+// that streams (e.g. server-sent events) are compressed from the first event on.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+	if cw.compressor != nil {
+		cw.compressor.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := cw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	cw.hijacked = true
+	return hijacker.Hijack()
+}
+
+// Unwrap returns the wrapped response writer (used by http.ResponseController)
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close writes buffered data of small responses and finishes the compressed stream
+func (cw *compressWriter) Close() error {
+	if cw.hijacked {
+		return nil
+	}
+	if !cw.decided {
+		if cw.status == 0 {
+			return nil
+		}
+		if err := cw.decide(false); err != nil {
+			return err
+		}
+	}
+	if cw.compressor != nil {
+		return cw.compressor.Close()
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// Compress returns a middleware, that compresses responses with gzip or deflate according
+// to the Accept-Encoding header of the request. Responses smaller than the minimum size,
+// with excluded content types or an existing Content-Encoding are sent unchanged. Streamed
+// responses are compressed as soon as they are flushed.
+func Compress(opts ...func(*CompressionConfig)) HandlerFunc[any] {
+	config := NewCompressionConfig(opts...)
+	return func(ctx *HttpCtx[any]) {
+		ctx.AddHeader("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(ctx.GetRawRequest().Header.Values("Accept-Encoding"))
+		if encoding == "" {
+			return
+		}
+		original := ctx.GetRawResponseWriter()
+		cw := &compressWriter{
+			ResponseWriter: original,
+			config:         config,
+			encoding:       encoding,
+			method:         ctx.GetRawRequest().Method,
+		}
+		ctx.SetRawResponseWriter(cw)
+		completed := false
+		defer func() {
+			// Middleware in front (e.g. Recovery) writes its response to the original writer.
+			// While a panic is unwinding, the buffered response is dropped instead of finished.
+			ctx.SetRawResponseWriter(original)
+			if completed {
+				cw.Close()
+			}
+		}()
+		ctx.Next()
+		completed = true
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------------------------------
+
+// WithCompressionLevel sets the compression level (see compress/flate, default
+// flate.DefaultCompression)
+func WithCompressionLevel(level int) func(*CompressionConfig) {
+	return func(cc *CompressionConfig) {
+		cc.level = level
+	}
+}
+
+// WithCompressionMinSize sets the minimum size of responses to compress (default 1KB)
+func WithCompressionMinSize(size int) func(*CompressionConfig) {
+	return func(cc *CompressionConfig) {
+		cc.minSize = size
+	}
+}
+
+// WithCompressionExcludedTypes adds content types, which are not compressed. Types ending
+// with "/*" exclude all subtypes (e.g. "image/*").
+func WithCompressionExcludedTypes(contentTypes ...string) func(*CompressionConfig) {
+	return func(cc *CompressionConfig) {
+		cc.excludedTypes = append(cc.excludedTypes, contentTypes...)
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// decide writes the header and buffered data, compressed if allowed and worth it
+func (cw *compressWriter) decide(compress bool) error {
+	cw.decided = true
+	header := cw.Header()
+	if header.Get("Content-Type") == "" && len(cw.buffer) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buffer))
+	}
+	if compress && cw.compressible() {
+		var err error
+		switch cw.encoding {
+		case "gzip":
+			cw.compressor, err = gzip.NewWriterLevel(cw.ResponseWriter, cw.config.level)
+		case "deflate":
+			// HTTP deflate is the zlib format (RFC 9110 8.4.1.2), not raw deflate
+			cw.compressor, err = zlib.NewWriterLevel(cw.ResponseWriter, cw.config.level)
+		}
+		if err != nil {
+			return err
+		}
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		// Strong validators do not match the compressed representation
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buffer := cw.buffer
+	cw.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	var err error
+	if cw.compressor != nil {
+		_, err = cw.compressor.Write(buffer)
+	} else {
+		_, err = cw.ResponseWriter.Write(buffer)
+	}
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	if cw.method == http.MethodHead || header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	switch cw.status {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return false
+	}
+	contentType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	mainType, _, _ := strings.Cut(contentType, "/")
+	return !slices.ContainsFunc(cw.config.excludedTypes, func(excluded string) bool {
+		return excluded == contentType || excluded == mainType+"/*"
+	})
+}
+
+// negotiateEncoding returns the preferred supported encoding of the Accept-Encoding header
+// or "" if the response must not be compressed
+func negotiateEncoding(acceptEncoding []string) string {
+	qualities := map[string]float64{}
+	for _, header := range acceptEncoding {
+		for _, part := range strings.Split(header, ",") {
+			coding, params, _ := strings.Cut(part, ";")
+			q := 1.0
+			if name, value, found := strings.Cut(strings.TrimSpace(params), "="); found && strings.TrimSpace(name) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+			qualities[strings.ToLower(strings.TrimSpace(coding))] = q
+		}
+	}
+	encoding, quality := "", 0.0
+	for _, candidate := range []string{"gzip", "deflate"} {
+		q, exists := qualities[candidate]
+		if !exists {
+			q = qualities["*"]
+		}
+		if q > quality {
+			encoding, quality = candidate, q
+		}
+	}
+	return encoding
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewCompressionConfig(opts ...func(*CompressionConfig)) *CompressionConfig {
+	c := &CompressionConfig{
+		level:   flate.DefaultCompression,
+		minSize: 1 << 10, // 1KB
+		excludedTypes: []string{
+			"image/*",
+			"video/*",
+			"audio/*",
+			"font/woff",
+			"font/woff2",
+			"application/gzip",
+			"application/zip",
+			"application/zstd",
+			"application/x-7z-compressed",
+			"application/x-rar-compressed",
+			"application/octet-stream",
+		},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
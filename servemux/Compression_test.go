@@ -0,0 +1,71 @@
+package servemux
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/uoul/go-common/log"
+)
+
+func TestCompressRecoversPanic(t *testing.T) {
+	handler := Handle(NewHandlerConfig(),
+		Recovery(log.NewConsoleLogger(log.ERROR)),
+		Compress(WithCompressionMinSize(1<<20)),
+		func(ctx *HttpCtx[any]) {
+			ctx.GetRawResponseWriter().Write([]byte("partial"))
+			panic("handler failed")
+		},
+	)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, MIME_PROBLEM_JSON) {
+		t.Errorf("Content-Type = %q, want %q", got, MIME_PROBLEM_JSON)
+	}
+	if strings.Contains(rec.Body.String(), "partial") || rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("unexpected partial response %q (Content-Encoding %q)", rec.Body.String(), rec.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestCompressEncodings(t *testing.T) {
+	body := strings.Repeat("compressible ", 200)
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+		reader         func(io.Reader) (io.Reader, error)
+	}{
+		{"gzip", "gzip", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"deflate", "deflate", func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) }},
+		{"br", "", func(r io.Reader) (io.Reader, error) { return r, nil }},
+	}
+	handler := Handle(NewHandlerConfig(), Compress(), func(ctx *HttpCtx[any]) {
+		ctx.SetResponseBody(body)
+	})
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			r, err := tt.reader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded, err := io.ReadAll(r); err != nil || string(decoded) != `"`+body+`"` {
+				t.Errorf("failed to decode %s response (%v)", tt.encoding, err)
+			}
+		})
+	}
+}
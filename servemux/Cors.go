@@ -0,0 +1,221 @@
+package servemux
+
+import (
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type CorsConfig struct {
+	allowedOrigins   []string
+	allowOriginFunc  func(origin string) bool
+	allowedMethods   []string
+	allowedHeaders   []string
+	exposedHeaders   []string
+	allowCredentials bool
+	maxAge           time.Duration
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// Cors returns a middleware, that adds the CORS headers for allowed origins. Preflight
+// requests (OPTIONS with Access-Control-Request-Method) are answered with 204 and abort
+// the handler chain. Requests from other origins are passed on without CORS headers, so
+// that browsers block the response.
+//
+// Register Cors as router or group middleware. Preflight requests only run the middleware,
+// so Cors passed to a single route (e.g. Get(group, "/items", Cors(), handler)) is not
+// applied to them. To limit Cors to a single route, register it for the OPTIONS method of
+// the route as well (HandleMethod(group, http.MethodOptions, "/items", Cors())).
+func Cors(opts ...func(*CorsConfig)) HandlerFunc[any] {
+	config := NewCorsConfig(opts...)
+	return func(ctx *HttpCtx[any]) {
+		req := ctx.GetRawRequest()
+		ctx.AddHeader("Vary", "Origin")
+		origin := req.Header.Get("Origin")
+		preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			ctx.AddHeader("Vary", "Access-Control-Request-Method")
+			ctx.AddHeader("Vary", "Access-Control-Request-Headers")
+		}
+		if origin == "" {
+			return
+		}
+		if !config.originAllowed(origin) {
+			if preflight {
+				ctx.AbortWithResponse(http.StatusNoContent, nil)
+			}
+			return
+		}
+		if !preflight {
+			config.setOriginHeaders(ctx, origin)
+			if len(config.exposedHeaders) > 0 {
+				ctx.SetHeader("Access-Control-Expose-Headers", strings.Join(config.exposedHeaders, ", "))
+			}
+			return
+		}
+		// Preflight: requested method and headers must be allowed
+		defer ctx.AbortWithResponse(http.StatusNoContent, nil)
+		method := strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+		if !slices.Contains(config.allowedMethods, method) {
+			return
+		}
+		headers := requestedHeaders(req)
+		if !slices.Contains(config.allowedHeaders, "*") {
+			for _, header := range headers {
+				if !slices.ContainsFunc(config.allowedHeaders, func(allowed string) bool { return strings.EqualFold(allowed, header) }) {
+					return
+				}
+			}
+		}
+		config.setOriginHeaders(ctx, origin)
+		ctx.SetHeader("Access-Control-Allow-Methods", strings.Join(config.allowedMethods, ", "))
+		if len(headers) > 0 {
+			ctx.SetHeader("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+		}
+		if config.maxAge > 0 {
+			ctx.SetHeader("Access-Control-Max-Age", strconv.Itoa(int(config.maxAge.Seconds())))
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------------------------------
+
+// WithCorsAllowedOrigins sets the allowed origins. "*" allows every origin, patterns like
+// "https://*.example.com" allow matching origins (see path.Match). Default: "*", which is
+// ignored with WithCorsAllowCredentials.
+func WithCorsAllowedOrigins(origins ...string) func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.allowedOrigins = origins
+	}
+}
+
+// WithCorsAllowOriginFunc sets a function deciding about origins, that are not allowed by
+// the configured origins
+func WithCorsAllowOriginFunc(allow func(origin string) bool) func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.allowOriginFunc = allow
+	}
+}
+
+// WithCorsAllowedMethods sets the allowed methods (default GET, HEAD, POST, PUT, PATCH, DELETE)
+func WithCorsAllowedMethods(methods ...string) func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.allowedMethods = []string{}
+		for _, method := range methods {
+			cc.allowedMethods = append(cc.allowedMethods, strings.ToUpper(method))
+		}
+	}
+}
+
+// WithCorsAllowedHeaders sets the allowed request headers, "*" allows all requested headers
+// (default Accept, Authorization, Content-Type)
+func WithCorsAllowedHeaders(headers ...string) func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.allowedHeaders = headers
+	}
+}
+
+// WithCorsExposedHeaders sets the response headers, that are readable by scripts
+func WithCorsExposedHeaders(headers ...string) func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.exposedHeaders = headers
+	}
+}
+
+// WithCorsAllowCredentials allows requests with credentials (cookies, authorization). The
+// origin is echoed instead of "*" then, as required by the CORS specification. Origins
+// must be allowed explicitly, by pattern or by WithCorsAllowOriginFunc, "*" allows none.
+func WithCorsAllowCredentials() func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.allowCredentials = true
+	}
+}
+
+// WithCorsMaxAge sets how long preflight responses may be cached (0 = not sent)
+func WithCorsMaxAge(maxAge time.Duration) func(*CorsConfig) {
+	return func(cc *CorsConfig) {
+		cc.maxAge = maxAge
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// originAllowed checks the origin against the configured origins. With credentials, "*"
+// allows no origin, because echoing any origin would expose credentialed responses to
+// every site.
+func (cc *CorsConfig) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range cc.allowedOrigins {
+		if allowed == "*" {
+			if cc.allowCredentials {
+				continue
+			}
+			return true
+		}
+		if matched, err := path.Match(strings.ToLower(allowed), origin); err == nil && matched {
+			return true
+		}
+	}
+	return cc.allowOriginFunc != nil && cc.allowOriginFunc(origin)
+}
+
+func (cc *CorsConfig) setOriginHeaders(ctx *HttpCtx[any], origin string) {
+	if !cc.allowCredentials && slices.Contains(cc.allowedOrigins, "*") {
+		ctx.SetHeader("Access-Control-Allow-Origin", "*")
+	} else {
+		ctx.SetHeader("Access-Control-Allow-Origin", origin)
+	}
+	if cc.allowCredentials {
+		ctx.SetHeader("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func requestedHeaders(req *http.Request) []string {
+	headers := []string{}
+	for _, value := range req.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewCorsConfig(opts ...func(*CorsConfig)) *CorsConfig {
+	c := &CorsConfig{
+		allowedOrigins: []string{"*"},
+		allowedMethods: []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPost,
+			http.MethodPut,
+			http.MethodPatch,
+			http.MethodDelete,
+		},
+		allowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+		exposedHeaders: []string{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
@@ -0,0 +1,44 @@
+package servemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCorsPreflight(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(router *Router)
+		allowed  bool
+	}{
+		{"router middleware", func(router *Router) {
+			router.Use(Cors())
+			Get(router, "/items", func(ctx *HttpCtx[any]) {})
+		}, true},
+		{"route handler only", func(router *Router) {
+			Get(router, "/items", Cors(), func(ctx *HttpCtx[any]) {})
+		}, false},
+		{"route handler with options route", func(router *Router) {
+			Get(router, "/items", Cors(), func(ctx *HttpCtx[any]) {})
+			HandleMethod(router, http.MethodOptions, "/items", Cors())
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := NewRouter(NewHandlerConfig())
+			tt.register(router)
+			req := httptest.NewRequest(http.MethodOptions, "/items", nil)
+			req.Header.Set("Origin", "https://example.com")
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNoContent {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+			}
+			if allowed := rec.Header().Get("Access-Control-Allow-Origin") != ""; allowed != tt.allowed {
+				t.Errorf("preflight allowed = %v, want %v", allowed, tt.allowed)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package servemux
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/uoul/go-common/validation"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------------------------------
+
+const (
+	MIME_MULTIPART_FORM  = "multipart/form-data"
+	MIME_URLENCODED_FORM = "application/x-www-form-urlencoded"
+)
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// mediaType returns the media type of the request without parameters (e.g. boundary, charset)
+func (h *httpCtxState) mediaType() string {
+	mediaType, _, err := mime.ParseMediaType(h.req.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// parseForm parses urlencoded or multipart forms once
+func (h *httpCtxState) parseForm() error {
+	if h.formParsed {
+		return h.formErr
+	}
+	h.formParsed = true
+	switch h.mediaType() {
+	case MIME_MULTIPART_FORM:
+		h.formErr = h.req.ParseMultipartForm(h.maxMemSize)
+	case MIME_URLENCODED_FORM:
+		h.formErr = h.req.ParseForm()
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(h.formErr, &maxBytesErr) {
+		h.formErr = bodyReadError(h.formErr)
+	} else if h.formErr != nil {
+		h.formErr = WrapHttpError(http.StatusBadRequest, fmt.Errorf("failed to parse form - %v", h.formErr))
+	}
+	return h.formErr
+}
+
+// bindFiles sets *multipart.FileHeader and []*multipart.FileHeader fields of the struct
+// pointed to by target to the uploaded files with the field's form name (`schema` tag or
+// field name). The content type of each bound file is sniffed from its content and
+// replaces the client provided Content-Type header. The tag `file:"maxSize=1048576,types=image/png|image/*"`
+// restricts size and sniffed content type per field.
+func (h *httpCtxState) bindFiles(target any, files map[string][]*multipart.FileHeader) error {
+	v := reflect.ValueOf(target)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	fieldErrors := []validation.FieldError{}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		isSingle := field.Type == fileHeaderType
+		isSlice := field.Type.Kind() == reflect.Slice && field.Type.Elem() == fileHeaderType
+		if !field.IsExported() || (!isSingle && !isSlice) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("schema"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		uploaded := files[name]
+		if len(uploaded) == 0 {
+			continue
+		}
+		maxSize, types := h.maxFileSize, []string{}
+		for _, rule := range strings.Split(field.Tag.Get("file"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			switch key {
+			case "maxSize":
+				if size, err := strconv.ParseInt(value, 10, 64); err == nil {
+					maxSize = size
+				}
+			case "types":
+				types = strings.Split(value, "|")
+			}
+		}
+		for _, fh := range uploaded {
+			if reason := checkFile(fh, maxSize, types); reason != "" {
+				fieldErrors = append(fieldErrors, validation.FieldError{Field: name, Reason: fmt.Sprintf("%s %s", fh.Filename, reason)})
+			}
+		}
+		if isSingle {
+			v.Field(i).Set(reflect.ValueOf(uploaded[0]))
+		} else {
+			v.Field(i).Set(reflect.ValueOf(uploaded))
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return &BindingError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+// checkFile sniffs the content type of the file and checks size and type restrictions
+func checkFile(fh *multipart.FileHeader, maxSize int64, types []string) string {
+	if maxSize > 0 && fh.Size > maxSize {
+		return fmt.Sprintf("exceeds maximum size of %d bytes", maxSize)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return "could not be read"
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+	sniffed := http.DetectContentType(head[:n])
+	fh.Header.Set("Content-Type", sniffed)
+	if len(types) == 0 {
+		return ""
+	}
+	sniffedType, _, _ := mime.ParseMediaType(sniffed)
+	for _, allowed := range types {
+		if allowed == sniffedType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(sniffedType, strings.TrimSuffix(allowed, "*"))) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("has unsupported type %s", sniffedType)
+}
@@ -1,14 +1,32 @@
 package servemux
 
-import "github.com/uoul/go-common/serialization"
+import (
+	"strings"
+	"time"
+
+	"github.com/uoul/go-common/log"
+	"github.com/uoul/go-common/serialization"
+)
 
 // -----------------------------------------------------------------------------------------------------------
 // Types
 // -----------------------------------------------------------------------------------------------------------
 
 type HandlerConfig struct {
-	serializer serialization.ISerializer
-	maxMemSize int64
+	serializer            serialization.ISerializer
+	maxMemSize            int64
+	maxFileSize           int64
+	maxBodySize           int64
+	validate              bool
+	disallowUnknownFields bool
+
+	sseHeartbeat time.Duration
+	logger       log.ILogger
+
+	// Content negotiation (media types in order of preference)
+	negotiate   bool
+	mediaTypes  []string
+	serializers map[string]serialization.ISerializer
 }
 
 // -----------------------------------------------------------------------------------------------------------
@@ -27,6 +45,91 @@ func WithHandlerMaxMemSize(size int64) func(*HandlerConfig) {
 	}
 }
 
+// WithHandlerMaxBodySize limits the size of request bodies including multipart uploads
+// (default 0 = unlimited). Larger bodies are rejected with 413, so the limit has to cover
+// the largest accepted upload.
+func WithHandlerMaxBodySize(size int64) func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.maxBodySize = size
+	}
+}
+
+// WithHandlerDisallowUnknownFields rejects JSON request bodies containing fields, that do
+// not exist in the body type, with 400
+func WithHandlerDisallowUnknownFields() func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.disallowUnknownFields = true
+	}
+}
+
+// WithHandlerMaxFileSize limits the size of each file bound to a *multipart.FileHeader
+// field of the request body (0 = unlimited)
+func WithHandlerMaxFileSize(size int64) func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.maxFileSize = size
+	}
+}
+
+// WithHandlerSseHeartbeat sets the interval of heartbeat comments sent on idle
+// server-sent event streams (default 15s, 0 disables heartbeats)
+func WithHandlerSseHeartbeat(interval time.Duration) func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.sseHeartbeat = interval
+	}
+}
+
+// WithHandlerLogger sets the logger returned by HttpCtx.Logger (default: console logger
+// with level INFO)
+func WithHandlerLogger(logger log.ILogger) func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.logger = logger
+	}
+}
+
+// WithHandlerValidation enables validation of request bodies in HttpCtx.GetBody using
+// `validate` struct tags (see validation.Validate)
+func WithHandlerValidation() func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.validate = true
+	}
+}
+
+// WithHandlerContentNegotiation enables content negotiation between JSON, XML and YAML.
+// The request body is parsed according to Content-Type and the response is rendered
+// according to Accept, answering 415 or 406 if no serializer matches.
+func WithHandlerContentNegotiation() func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.negotiate = true
+		hc.addMediaType("application/json", serialization.NewJSONSerializer())
+		hc.addMediaType("application/xml", serialization.NewXmlSerializer())
+		hc.addMediaType("text/xml", serialization.NewXmlSerializer())
+		hc.addMediaType("application/yaml", serialization.NewYamlSerializer())
+		hc.addMediaType("application/x-yaml", serialization.NewYamlSerializer())
+		hc.addMediaType("text/yaml", serialization.NewYamlSerializer())
+	}
+}
+
+// WithHandlerMediaType enables content negotiation and registers (or replaces) the
+// serializer for the given media type. The first registered media type is the default.
+func WithHandlerMediaType(mediaType string, serializer serialization.ISerializer) func(*HandlerConfig) {
+	return func(hc *HandlerConfig) {
+		hc.negotiate = true
+		hc.addMediaType(mediaType, serializer)
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (hc *HandlerConfig) addMediaType(mediaType string, serializer serialization.ISerializer) {
+	mediaType = strings.ToLower(mediaType)
+	if _, exists := hc.serializers[mediaType]; !exists {
+		hc.mediaTypes = append(hc.mediaTypes, mediaType)
+	}
+	hc.serializers[mediaType] = serializer
+}
+
 // -----------------------------------------------------------------------------------------------------------
 // Constructor
 // -----------------------------------------------------------------------------------------------------------
@@ -34,7 +137,12 @@ func WithHandlerMaxMemSize(size int64) func(*HandlerConfig) {
 func NewHandlerConfig(opts ...func(*HandlerConfig)) *HandlerConfig {
 	c := &HandlerConfig{
 		serializer: &serialization.JsonSerializer{},
-		maxMemSize: 32 << 20, // 20MB
+		maxMemSize: 32 << 20, // 32MB
+
+		sseHeartbeat: 15 * time.Second,
+		logger:       log.NewConsoleLogger(log.INFO),
+
+		serializers: map[string]serialization.ISerializer{},
 	}
 	for _, o := range opts {
 		o(c)
@@ -1,16 +1,23 @@
 package servemux
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"reflect"
+	"time"
 
 	"github.com/gorilla/schema"
-	"github.com/uoul/go-common/collections"
+	"github.com/uoul/go-common/log"
 	"github.com/uoul/go-common/serialization"
+	"github.com/uoul/go-common/validation"
 )
 
 // -----------------------------------------------------------------------------------------------------------
@@ -18,51 +25,88 @@ import (
 // -----------------------------------------------------------------------------------------------------------
 
 type HttpCtx[T any] struct {
-	req        *http.Request
-	respWriter http.ResponseWriter
-	serializer serialization.ISerializer
-	maxMemSize int64
-	decoder    *schema.Decoder
+	*httpCtxState
+}
+
+// httpCtxState holds the request state, so that typed and untyped views (e.g. for
+// group middleware) of the same request share status, response body and errors
+type httpCtxState struct {
+	req            *http.Request
+	respWriter     http.ResponseWriter
+	reqSerializer  serialization.ISerializer
+	respSerializer serialization.ISerializer
+	maxMemSize     int64
+	maxFileSize    int64
+	sseHeartbeat   time.Duration
+	logger         log.ILogger
+	decoder        *schema.Decoder
+	validate       bool
+	formParsed     bool
+	formErr        error
+
+	disallowUnknownFields bool
+
+	// Content negotiation
+	respContentType      string
+	unsupportedMediaType bool
+	notAcceptable        bool
 
 	statusCode   int
 	responseBody any
 	aborted      bool
 	errors       []error
-}
+	values       map[any]any
 
-type ServerSentEvent struct {
-	Event string // Event type (e.g. "message")
-	Data  any    // Data (will be serialized using given serializer)
-}
-
-func (s *ServerSentEvent) marshal(serializer serialization.ISerializer) ([]byte, error) {
-	data, err := serializer.Marshal(s.Data)
-	if err != nil {
-		return nil, err
-	}
-	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", s.Event, data)), nil
+	// Handler chain
+	chain   []func()
+	index   int
+	written bool
 }
 
 // -----------------------------------------------------------------------------------------------------------
 // Public
 // -----------------------------------------------------------------------------------------------------------
 
+// GetBody decodes the request body into T. If validation is enabled for the handler,
+// the body is validated afterwards. The error is not recorded on the context, pass it to
+// AbortWithError to answer with a 400 problem response listing all invalid fields.
 func (h *HttpCtx[T]) GetBody() (T, error) {
-	contentType := h.GetHeader("Content-Type")
-	if collections.ContainsSlice(contentType, func(t string) bool { return t == "multipart/form-data" }) {
-		return h.parseFormData(h.req.Form)
-	} else if collections.ContainsSlice(contentType, func(t string) bool { return t == "application/x-www-form-urlencoded" }) {
-		return h.parseFormData(h.req.MultipartForm.Value)
-	} else {
-		return h.parseBody()
+	body, err := h.decodeBody()
+	if err != nil {
+		return *new(T), err
 	}
+	if h.validate {
+		if err := validation.Validate(&body); err != nil {
+			return *new(T), err
+		}
+	}
+	return body, nil
 }
 
 func (h *HttpCtx[T]) Context() context.Context {
 	return h.req.Context()
 }
 
+// SetContext replaces the context of the request for all following handlers
+func (h *HttpCtx[T]) SetContext(ctx context.Context) {
+	h.req = h.req.WithContext(ctx)
+}
+
+// Logger returns the logger of the handler config, that prefixes all messages with the
+// request id and trace id of the request context (see RequestTracing)
+func (h *HttpCtx[T]) Logger() log.ILogger {
+	// Contexts created by NewHttpCtx have no handler config
+	if h.logger == nil {
+		h.logger = log.NewConsoleLogger(log.INFO)
+	}
+	return log.WithContext(h.logger, h.Context())
+}
+
+// GetFiles returns the uploaded files of a multipart request (empty for other requests)
 func (h *HttpCtx[T]) GetFiles() map[string][]*multipart.FileHeader {
+	if err := h.parseForm(); err != nil || h.req.MultipartForm == nil {
+		return map[string][]*multipart.FileHeader{}
+	}
 	return h.req.MultipartForm.File
 }
 
@@ -102,10 +146,26 @@ func (h *HttpCtx[T]) Errors() []error {
 	return h.errors
 }
 
+// Error collects the error. If any errors are collected, the response is rendered as
+// application/problem+json (RFC 7807) instead of the response body. Errors implementing
+// IHttpError choose the status code, otherwise an error status set on the context or 500
+// is used.
 func (h *HttpCtx[T]) Error(err error) {
 	h.errors = append(h.errors, err)
 }
 
+func (h *HttpCtx[T]) AbortWithError(err error) {
+	h.Error(err)
+	h.Abort()
+}
+
+// Next runs the remaining handlers of the chain and writes the response. It is used by
+// middleware to run code after the following handlers (e.g. recover panics or log the
+// response). Without calling Next, the following handlers run after the current one.
+func (h *HttpCtx[T]) Next() {
+	h.next()
+}
+
 func (h *HttpCtx[T]) Abort() {
 	h.aborted = true
 }
@@ -124,39 +184,6 @@ func (h *HttpCtx[T]) SetResponseBody(value any) {
 	h.responseBody = value
 }
 
-func (h *HttpCtx[T]) Stream(step func() (ServerSentEvent, bool)) (bool, error) {
-	// Set Headers for streaming
-	h.respWriter.Header().Set("Content-Type", "text/event-stream")
-	h.respWriter.Header().Set("Cache-Control", "no-cache")
-	h.respWriter.Header().Set("Connection", "keep-alive")
-	h.respWriter.Header().Set("Transfer-Encoding", "chunked")
-	// Create http flusher
-	flusher, ok := h.respWriter.(http.Flusher)
-	if !ok {
-		return false, fmt.Errorf("failed to create http flusher")
-	}
-	// Run Stream
-	for {
-		select {
-		case <-h.Context().Done():
-			return true, nil
-		default:
-			msg, proceed := step()
-			if !proceed {
-				return false, nil
-			}
-			sse, err := msg.marshal(h.serializer)
-			if err != nil {
-				return false, err
-			}
-			if _, err := h.respWriter.Write([]byte(sse)); err != nil {
-				return false, err
-			}
-			flusher.Flush()
-		}
-	}
-}
-
 func (h *HttpCtx[T]) GetRawRequest() *http.Request {
 	return h.req
 }
@@ -165,53 +192,153 @@ func (h *HttpCtx[T]) GetRawResponseWriter() http.ResponseWriter {
 	return h.respWriter
 }
 
+// SetRawResponseWriter replaces the response writer for all following writes, e.g. to
+// wrap it in middleware
+func (h *HttpCtx[T]) SetRawResponseWriter(w http.ResponseWriter) {
+	h.respWriter = w
+}
+
 // -----------------------------------------------------------------------------------------------------------
 // Private
 // -----------------------------------------------------------------------------------------------------------
 
+func (h *HttpCtx[T]) decodeBody() (T, error) {
+	switch h.mediaType() {
+	case MIME_MULTIPART_FORM:
+		if err := h.parseForm(); err != nil {
+			return *new(T), err
+		}
+		body, err := h.parseFormData(h.req.MultipartForm.Value)
+		if err != nil {
+			return *new(T), err
+		}
+		if err := h.bindFiles(&body, h.req.MultipartForm.File); err != nil {
+			return *new(T), err
+		}
+		return body, nil
+	case MIME_URLENCODED_FORM:
+		if err := h.parseForm(); err != nil {
+			return *new(T), err
+		}
+		return h.parseFormData(h.req.PostForm)
+	default:
+		return h.parseBody()
+	}
+}
+
 func (h *HttpCtx[T]) parseFormData(data url.Values) (T, error) {
 	body := *new(T)
-	err := h.decoder.Decode(&body, data)
-	return body, err
+	if err := h.decoder.Decode(&body, data); err != nil {
+		return *new(T), WrapHttpError(http.StatusBadRequest, err)
+	}
+	return body, nil
 }
 
+// parseBody decodes the request body. Empty bodies (and JSON null for types, that are not
+// nullable) are rejected instead of being decoded as zero value.
 func (h *HttpCtx[T]) parseBody() (T, error) {
 	raw, err := io.ReadAll(h.req.Body)
 	if err != nil {
-		return *new(T), err
+		return *new(T), bodyReadError(err)
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return *new(T), NewHttpError(http.StatusBadRequest, "request body is empty")
+	}
+	_, isJson := h.reqSerializer.(*serialization.JsonSerializer)
+	if isJson && bytes.Equal(raw, []byte("null")) {
+		switch reflect.TypeFor[T]().Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		default:
+			return *new(T), NewHttpError(http.StatusBadRequest, "request body must not be null")
+		}
 	}
 	body := *new(T)
-	if err := h.serializer.Unmarshal(raw, &body); err != nil {
-		return *new(T), err
+	if isJson && h.disallowUnknownFields {
+		err = decodeJsonStrict(raw, &body)
+	} else {
+		err = h.reqSerializer.Unmarshal(raw, &body)
+	}
+	if err != nil {
+		return *new(T), WrapHttpError(http.StatusBadRequest, err)
 	}
 	return body, nil
 }
 
+// decodeJsonStrict decodes a single JSON value and rejects unknown fields
+func decodeJsonStrict(raw []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after json value")
+	}
+	return nil
+}
+
+// bodyReadError answers bodies exceeding the limit with 413
+func bodyReadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewHttpError(http.StatusRequestEntityTooLarge, "request body exceeds the limit of %d bytes", maxBytesErr.Limit)
+	}
+	return WrapHttpError(http.StatusBadRequest, fmt.Errorf("failed to read request body - %v", err))
+}
+
+// negotiate selects request and response serializer according to Content-Type and Accept.
+// If no response serializer matches, the default one stays selected for error responses.
+func (h *httpCtxState) negotiate(config *HandlerConfig) {
+	contentType := h.req.Header.Get("Content-Type")
+	if !isFormContentType(contentType) {
+		if serializer, ok := config.negotiateRequest(contentType); ok {
+			h.reqSerializer = serializer
+		} else if h.req.ContentLength != 0 {
+			h.unsupportedMediaType = true
+		}
+	}
+	if mediaType, serializer, ok := config.negotiateResponse(h.req.Header.Values("Accept")); ok {
+		h.respContentType = mediaType
+		h.respSerializer = serializer
+	} else {
+		h.respContentType = config.mediaTypes[0]
+		h.respSerializer = config.serializers[config.mediaTypes[0]]
+		h.notAcceptable = true
+	}
+}
+
+func isFormContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == MIME_MULTIPART_FORM || mediaType == MIME_URLENCODED_FORM
+}
+
+// convertHttpCtx returns a view of the given context with a different body type
+func convertHttpCtx[T, U any](h *HttpCtx[T]) *HttpCtx[U] {
+	return &HttpCtx[U]{h.httpCtxState}
+}
+
 // -----------------------------------------------------------------------------------------------------------
 // Constructor
 // -----------------------------------------------------------------------------------------------------------
 
 func NewHttpCtx[T any](req *http.Request, responseWriter http.ResponseWriter, serializer serialization.ISerializer, maxMemSize int64) *HttpCtx[T] {
 	// Create new HttpCtx
-	h := &HttpCtx[T]{
-		req:        req,
-		respWriter: responseWriter,
-		serializer: serializer,
-		maxMemSize: maxMemSize,
+	h := &HttpCtx[T]{&httpCtxState{
+		req:            req,
+		respWriter:     responseWriter,
+		reqSerializer:  serializer,
+		respSerializer: serializer,
+		maxMemSize:     maxMemSize,
 
 		statusCode:   http.StatusOK,
 		decoder:      schema.NewDecoder(),
 		responseBody: nil,
 		errors:       []error{},
 		aborted:      false,
-	}
-	// Parse Form data if form data encoding
-	contentType := h.GetHeader("Content-Type")
-	if collections.ContainsSlice(contentType, func(t string) bool { return t == "multipart/form-data" }) {
-		h.req.ParseMultipartForm(h.maxMemSize)
-	} else if collections.ContainsSlice(contentType, func(t string) bool { return t == "application/x-www-form-urlencoded" }) {
-		h.req.ParseForm()
-	}
+
+		index: -1,
+	}}
 	// Return context
 	return h
 }
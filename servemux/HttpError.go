@@ -0,0 +1,50 @@
+package servemux
+
+import (
+	"fmt"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type HttpError struct {
+	statusCode int
+	err        error
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// Error implements IHttpError.
+func (e *HttpError) Error() string {
+	return e.err.Error()
+}
+
+// StatusCode implements IHttpError.
+func (e *HttpError) StatusCode() int {
+	return e.statusCode
+}
+
+func (e *HttpError) Unwrap() error {
+	return e.err
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewHttpError(statusCode int, format string, a ...any) IHttpError {
+	return &HttpError{
+		statusCode: statusCode,
+		err:        fmt.Errorf(format, a...),
+	}
+}
+
+func WrapHttpError(statusCode int, err error) IHttpError {
+	return &HttpError{
+		statusCode: statusCode,
+		err:        err,
+	}
+}
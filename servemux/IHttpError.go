@@ -0,0 +1,8 @@
+package servemux
+
+// IHttpError can be implemented by errors passed to HttpCtx.Error to choose the HTTP
+// status code of the problem response
+type IHttpError interface {
+	error
+	StatusCode() int
+}
@@ -0,0 +1,18 @@
+package servemux
+
+import "time"
+
+// IRateLimitAlgorithm decides about requests based on the state stored per key
+type IRateLimitAlgorithm interface {
+	// Take consumes one request from the state (nil for unknown keys) and returns the new
+	// state and the result. States are JSON encoded (e.g. TokenBucketState), so that stores
+	// can persist them as is, e.g. in Redis using a compare-and-swap loop.
+	Take(state []byte, now time.Time) ([]byte, RateLimitResult)
+	// TakeState works like Take on the decoded state (e.g. *TokenBucketState), for stores
+	// keeping the state in memory. The state may be updated in place.
+	TakeState(state any, now time.Time) (any, RateLimitResult)
+	// Ttl returns how long the state of an idle key has to be kept
+	Ttl() time.Duration
+	// Policy returns the quota policy for the RateLimit-Policy header (e.g. "100;w=60")
+	Policy() string
+}
@@ -0,0 +1,10 @@
+package servemux
+
+import "context"
+
+// IRateLimitStore holds the rate limit state of all keys
+type IRateLimitStore interface {
+	// Take atomically applies the algorithm to the state of the key and stores the new state,
+	// which expires after the Ttl of the algorithm
+	Take(ctx context.Context, key string, algorithm IRateLimitAlgorithm) (RateLimitResult, error)
+}
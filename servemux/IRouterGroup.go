@@ -0,0 +1,17 @@
+package servemux
+
+import (
+	"net/http"
+	"reflect"
+)
+
+type IRouterGroup interface {
+	// Group creates a sub group, whose routes share the given path prefix and run the
+	// given middleware after the middleware of this group
+	Group(prefix string, middleware ...HandlerFunc[any]) IRouterGroup
+	// Use appends middleware for all routes registered afterwards on this group and its
+	// sub groups (including sub groups created before)
+	Use(middleware ...HandlerFunc[any])
+
+	register(method string, pattern string, bodyType reflect.Type, build func(config *HandlerConfig, middleware []HandlerFunc[any]) http.HandlerFunc) *RouteInfo
+}
@@ -0,0 +1,14 @@
+package servemux
+
+import "github.com/uoul/go-common/resource"
+
+type IServer interface {
+	resource.IResource
+	// Start binds the listener and serves requests in the background. Listen errors are
+	// returned, errors while serving are logged.
+	Start() error
+	// Addr returns the address of the listener (e.g. the chosen port for ":0")
+	Addr() string
+	// ReadinessCheck returns an error, if the server is not started or shutting down
+	ReadinessCheck() error
+}
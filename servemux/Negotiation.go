@@ -0,0 +1,110 @@
+package servemux
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/uoul/go-common/serialization"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type mediaRange struct {
+	mainType string
+	subType  string
+	q        float64
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// negotiateRequest returns the serializer for the given Content-Type header. Structured
+// syntax suffixes (e.g. application/vnd.api+json) fall back to the base media type.
+func (c *HandlerConfig) negotiateRequest(contentType string) (serialization.ISerializer, bool) {
+	if contentType == "" {
+		return c.serializers[c.mediaTypes[0]], true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	if serializer, exists := c.serializers[mediaType]; exists {
+		return serializer, true
+	}
+	if idx := strings.LastIndex(mediaType, "+"); idx >= 0 {
+		mainType, _, _ := strings.Cut(mediaType, "/")
+		serializer, exists := c.serializers[mainType+"/"+mediaType[idx+1:]]
+		return serializer, exists
+	}
+	return nil, false
+}
+
+// negotiateResponse returns the media type and serializer matching the given Accept
+// headers best. Equally weighted media types are chosen in registration order.
+func (c *HandlerConfig) negotiateResponse(accept []string) (string, serialization.ISerializer, bool) {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return c.mediaTypes[0], c.serializers[c.mediaTypes[0]], true
+	}
+	bestType, bestQ := "", 0.0
+	for _, mediaType := range c.mediaTypes {
+		if q := acceptQuality(ranges, mediaType); q > bestQ {
+			bestType, bestQ = mediaType, q
+		}
+	}
+	if bestType == "" {
+		return "", nil, false
+	}
+	return bestType, c.serializers[bestType], true
+}
+
+// acceptQuality returns the q-value of the most specific range matching the media type
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	mainType, subType, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.mainType == mainType && r.subType == subType:
+			s = 2
+		case r.mainType == mainType && r.subType == "*":
+			s = 1
+		case r.mainType == "*" && r.subType == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+func parseAccept(accept []string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, header := range accept {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			mainType, subType, found := strings.Cut(mediaType, "/")
+			if !found {
+				continue
+			}
+			q := 1.0
+			if raw, exists := params["q"]; exists {
+				if parsed, err := strconv.ParseFloat(raw, 64); err == nil {
+					q = parsed
+				}
+			}
+			ranges = append(ranges, mediaRange{mainType: mainType, subType: subType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
@@ -0,0 +1,109 @@
+package servemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateResponse(t *testing.T) {
+	config := NewHandlerConfig(WithHandlerContentNegotiation())
+	tests := []struct {
+		name   string
+		accept []string
+		want   string
+		ok     bool
+	}{
+		{"no accept header uses default", nil, "application/json", true},
+		{"exact match", []string{"application/xml"}, "application/xml", true},
+		{"highest q wins", []string{"application/json;q=0.5, application/yaml;q=0.9"}, "application/yaml", true},
+		{"multiple headers", []string{"text/html", "application/yaml;q=0.8"}, "application/yaml", true},
+		{"full wildcard uses registration order", []string{"*/*"}, "application/json", true},
+		{"subtype wildcard", []string{"text/*"}, "text/xml", true},
+		{"specific range overrides wildcard", []string{"*/*;q=0.1, application/xml"}, "application/xml", true},
+		{"q=0 excludes media type", []string{"application/json;q=0, */*;q=0.5"}, "application/xml", true},
+		{"q=0 on wildcard excludes all others", []string{"application/yaml, */*;q=0"}, "application/yaml", true},
+		{"equal q uses registration order", []string{"application/yaml, application/xml"}, "application/xml", true},
+		{"invalid q counts as 1", []string{"application/yaml;q=abc, application/json;q=0.5"}, "application/yaml", true},
+		{"malformed ranges are skipped", []string{"invalid, application/xml"}, "application/xml", true},
+		{"no match", []string{"text/html"}, "", false},
+		{"everything excluded", []string{"*/*;q=0"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, ok := config.negotiateResponse(tt.accept)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("negotiateResponse(%q) = %q, %v, want %q, %v", tt.accept, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNegotiateRequest(t *testing.T) {
+	config := NewHandlerConfig(WithHandlerContentNegotiation())
+	tests := []struct {
+		name        string
+		contentType string
+		ok          bool
+	}{
+		{"missing content type uses default", "", true},
+		{"known media type", "application/yaml", true},
+		{"parameters are ignored", "application/json; charset=utf-8", true},
+		{"structured syntax suffix", "application/vnd.api+json", true},
+		{"unknown media type", "text/csv", false},
+		{"unknown suffix", "application/vnd.api+cbor", false},
+		{"malformed", "application/json;;", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := config.negotiateRequest(tt.contentType); ok != tt.ok {
+				t.Errorf("negotiateRequest(%q) = %v, want %v", tt.contentType, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestNegotiationStatus(t *testing.T) {
+	handler := Handle(NewHandlerConfig(WithHandlerContentNegotiation()), func(ctx *HttpCtx[map[string]string]) {
+		if ctx.GetRawRequest().ContentLength > 0 {
+			if _, err := ctx.GetBody(); err != nil {
+				ctx.AbortWithError(err)
+				return
+			}
+		}
+		ctx.SetResponseBody(map[string]string{"name": "value"})
+	})
+	tests := []struct {
+		name        string
+		contentType string
+		accept      string
+		body        string
+		status      int
+		respType    string
+	}{
+		{"json", "", "application/json", "", http.StatusOK, "application/json"},
+		{"yaml", "", "application/yaml", "", http.StatusOK, "application/yaml"},
+		{"not acceptable", "", "text/html", "", http.StatusNotAcceptable, MIME_PROBLEM_JSON},
+		{"unsupported media type", "text/csv", "", "a,b", http.StatusUnsupportedMediaType, MIME_PROBLEM_JSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.status, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.respType) {
+				t.Errorf("Content-Type = %q, want %q", got, tt.respType)
+			}
+		})
+	}
+}
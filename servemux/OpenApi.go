@@ -0,0 +1,337 @@
+package servemux
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoul/go-common/serialization"
+	"github.com/uoul/go-common/validation"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------------------------------
+
+const (
+	OPENAPI_VERSION = "3.0.3"
+)
+
+var pathParamPattern = regexp.MustCompile(`\{([^}.$]+)(\.\.\.)?\}`)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type OpenApiDocument struct {
+	OpenApi    string                                  `json:"openapi" yaml:"openapi"`
+	Info       OpenApiInfo                             `json:"info" yaml:"info"`
+	Servers    []OpenApiServer                         `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Paths      map[string]map[string]*OpenApiOperation `json:"paths" yaml:"paths"`
+	Components *OpenApiComponents                      `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+type OpenApiInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type OpenApiServer struct {
+	Url         string `json:"url" yaml:"url"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type OpenApiComponents struct {
+	Schemas map[string]*OpenApiSchema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+type OpenApiOperation struct {
+	Summary     string                      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                      `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationId string                      `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Parameters  []*OpenApiParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*OpenApiResponse `json:"responses" yaml:"responses"`
+}
+
+type OpenApiParameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"`
+	Required bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *OpenApiSchema `json:"schema" yaml:"schema"`
+}
+
+type OpenApiRequestBody struct {
+	Required bool                         `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]*OpenApiMediaType `json:"content" yaml:"content"`
+}
+
+type OpenApiResponse struct {
+	Description string                       `json:"description" yaml:"description"`
+	Content     map[string]*OpenApiMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type OpenApiMediaType struct {
+	Schema *OpenApiSchema `json:"schema" yaml:"schema"`
+}
+
+type OpenApiSchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Items                *OpenApiSchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*OpenApiSchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *OpenApiSchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Default              any                       `json:"default,omitempty" yaml:"default,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	MinProperties        *int                      `json:"minProperties,omitempty" yaml:"minProperties,omitempty"`
+	MaxProperties        *int                      `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// OpenApi generates the OpenAPI 3 document of all routes registered on the router. Request
+// bodies are documented by the body type of the handlers, parameters and responses by the
+// RouteInfo of the routes. Every operation documents problem details as default response.
+func (r *Router) OpenApi(info OpenApiInfo, servers ...OpenApiServer) *OpenApiDocument {
+	generator := newSchemaGenerator()
+	problem := generator.schema(reflect.TypeFor[ProblemDetails]())
+	paths := map[string]map[string]*OpenApiOperation{}
+	for _, ri := range r.infos {
+		if ri.hidden {
+			continue
+		}
+		path := openApiPath(ri.path)
+		if _, exists := paths[path]; !exists {
+			paths[path] = map[string]*OpenApiOperation{}
+		}
+		op := ri.operation(generator)
+		op.Responses["default"] = &OpenApiResponse{
+			Description: "Error",
+			Content:     map[string]*OpenApiMediaType{MIME_PROBLEM_JSON: {Schema: problem}},
+		}
+		paths[path][strings.ToLower(ri.method)] = op
+	}
+	return &OpenApiDocument{
+		OpenApi:    OPENAPI_VERSION,
+		Info:       info,
+		Servers:    servers,
+		Paths:      paths,
+		Components: &OpenApiComponents{Schemas: generator.schemas},
+	}
+}
+
+// ServeOpenApi registers a GET route on the router serving the OpenAPI document. It is
+// rendered as YAML, if the pattern ends with .yaml or .yml, otherwise as JSON. The route
+// itself is not documented.
+func (r *Router) ServeOpenApi(pattern string, info OpenApiInfo, servers ...OpenApiServer) *RouteInfo {
+	serializer, contentType := serialization.NewJSONSerializer(), "application/json"
+	if strings.HasSuffix(pattern, ".yaml") || strings.HasSuffix(pattern, ".yml") {
+		serializer, contentType = serialization.NewYamlSerializer(), "application/yaml"
+	}
+	return Get(&r.RouterGroup, pattern, func(ctx *HttpCtx[any]) {
+		data, err := serializer.Marshal(r.OpenApi(info, servers...))
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		ctx.WriteReader(contentType, int64(len(data)), bytes.NewReader(data))
+	}).Hidden()
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (ri *RouteInfo) operation(generator *schemaGenerator) *OpenApiOperation {
+	op := &OpenApiOperation{
+		Summary:     ri.summary,
+		Description: ri.description,
+		OperationId: ri.operationId,
+		Tags:        ri.tags,
+		Deprecated:  ri.deprecated,
+		Parameters:  []*OpenApiParameter{},
+		Responses:   map[string]*OpenApiResponse{},
+	}
+	// Parameters
+	for _, t := range ri.paramTypes {
+		if t = derefType(t); t.Kind() == reflect.Struct {
+			op.Parameters = append(op.Parameters, paramsOf(t, generator)...)
+		}
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(ri.path, -1) {
+		documented := slices.ContainsFunc(op.Parameters, func(p *OpenApiParameter) bool {
+			return p.In == "path" && p.Name == match[1]
+		})
+		if !documented {
+			op.Parameters = append(op.Parameters, &OpenApiParameter{
+				Name:     match[1],
+				In:       "path",
+				Required: true,
+				Schema:   &OpenApiSchema{Type: "string"},
+			})
+		}
+	}
+	// Request body, types with parameter fields only are bound without body
+	if ri.bodyType != nil && ri.method != http.MethodGet && ri.method != http.MethodHead && hasBodyFields(ri.bodyType) {
+		mediaTypes := ri.config.documentedMediaTypes()
+		if hasFileFields(ri.bodyType) {
+			mediaTypes = []string{MIME_MULTIPART_FORM}
+		}
+		op.RequestBody = &OpenApiRequestBody{
+			Required: true,
+			Content:  mediaTypeContent(mediaTypes, generator.schema(ri.bodyType)),
+		}
+	}
+	// Responses
+	if len(ri.responses) == 0 {
+		op.Responses[strconv.Itoa(http.StatusOK)] = &OpenApiResponse{Description: http.StatusText(http.StatusOK)}
+	}
+	for _, resp := range ri.responses {
+		response := &OpenApiResponse{Description: resp.description}
+		if resp.bodyType != nil {
+			response.Content = mediaTypeContent(ri.config.documentedMediaTypes(), generator.schema(resp.bodyType))
+		}
+		op.Responses[strconv.Itoa(resp.status)] = response
+	}
+	return op
+}
+
+// hasBodyFields checks whether the type has fields, that are not bound by HttpCtx.BindParams
+func hasBodyFields(t reflect.Type) bool {
+	if t = derefType(t); t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag == "" {
+			if hasBodyFields(field.Type) {
+				return true
+			}
+			continue
+		}
+		if !isParamField(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// isParamField checks whether the field is bound from path, query or header
+func isParamField(field reflect.StructField) bool {
+	return slices.ContainsFunc([]string{"path", "query", "header"}, func(tag string) bool {
+		_, exists := field.Tag.Lookup(tag)
+		return exists
+	})
+}
+
+// paramsOf documents the fields of a struct bound by HttpCtx.BindParams
+func paramsOf(t reflect.Type, generator *schemaGenerator) []*OpenApiParameter {
+	params := []*OpenApiParameter{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag == "" {
+			params = append(params, paramsOf(field.Type, generator)...)
+			continue
+		}
+		param := &OpenApiParameter{}
+		for _, in := range []string{"path", "query", "header"} {
+			if name, exists := field.Tag.Lookup(in); exists {
+				param.Name, param.In = name, in
+				break
+			}
+		}
+		if param.In == "" {
+			continue
+		}
+		// Durations are bound from their string representation
+		if derefType(field.Type) == reflect.TypeFor[time.Duration]() {
+			param.Schema = &OpenApiSchema{Type: "string"}
+		} else {
+			param.Schema = generator.schema(field.Type)
+		}
+		if rules, exists := field.Tag.Lookup(validation.TAG); exists {
+			param.Required = applyRules(param.Schema, rules)
+		}
+		if defaultValue, exists := field.Tag.Lookup("default"); exists {
+			param.Schema.Default = enumValue(param.Schema.Type, defaultValue)
+		}
+		param.Required = param.Required || param.In == "path"
+		params = append(params, param)
+	}
+	return params
+}
+
+// documentedMediaTypes returns the media types of request and response bodies
+func (hc *HandlerConfig) documentedMediaTypes() []string {
+	if hc.negotiate {
+		return hc.mediaTypes
+	}
+	switch hc.serializer.(type) {
+	case *serialization.XmlSerializer:
+		return []string{"application/xml"}
+	case *serialization.YamlSerializer:
+		return []string{"application/yaml"}
+	default:
+		return []string{"application/json"}
+	}
+}
+
+func mediaTypeContent(mediaTypes []string, schema *OpenApiSchema) map[string]*OpenApiMediaType {
+	content := map[string]*OpenApiMediaType{}
+	for _, mediaType := range mediaTypes {
+		content[mediaType] = &OpenApiMediaType{Schema: schema}
+	}
+	return content
+}
+
+func hasFileFields(t reflect.Type) bool {
+	t = derefType(t)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		ft := derefType(t.Field(i).Type)
+		if ft.Kind() == reflect.Slice {
+			ft = derefType(ft.Elem())
+		}
+		if ft == fileHeaderType.Elem() {
+			return true
+		}
+	}
+	return false
+}
+
+// openApiPath converts a ServeMux pattern into an OpenAPI path ("/files/{path...}" to
+// "/files/{path}", "/{$}" to "/")
+func openApiPath(pattern string) string {
+	pattern = strings.TrimSuffix(pattern, "{$}")
+	if pattern == "" {
+		pattern = "/"
+	}
+	return pathParamPattern.ReplaceAllString(pattern, "{$1}")
+}
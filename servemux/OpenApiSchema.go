@@ -0,0 +1,236 @@
+package servemux
+
+import (
+	"encoding"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uoul/go-common/validation"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// schemaGenerator creates schemas by reflection. Named struct types are added to the
+// components and referenced.
+type schemaGenerator struct {
+	schemas map[string]*OpenApiSchema
+	names   map[reflect.Type]string
+}
+
+var (
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+	timeType          = reflect.TypeFor[time.Time]()
+	invalidNameChars  = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (g *schemaGenerator) schema(t reflect.Type) *OpenApiSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &OpenApiSchema{Type: "string", Format: "date-time"}
+	case t == fileHeaderType.Elem():
+		return &OpenApiSchema{Type: "string", Format: "binary"}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &OpenApiSchema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenApiSchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &OpenApiSchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &OpenApiSchema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &OpenApiSchema{Type: "integer", Minimum: new(float64)}
+	case reflect.Float32:
+		return &OpenApiSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenApiSchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenApiSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenApiSchema{Type: "string", Format: "byte"}
+		}
+		return &OpenApiSchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenApiSchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		return &OpenApiSchema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// Interfaces and unsupported kinds allow any value
+		return &OpenApiSchema{}
+	}
+}
+
+// component adds the schema of the named struct type to the components once and returns
+// its name
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, exists := g.names[t]; exists {
+		return name
+	}
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if _, exists := g.schemas[name]; exists {
+		name = invalidNameChars.ReplaceAllString(path.Base(t.PkgPath()), "_") + "." + name
+	}
+	// Register before creating the schema to support recursive types
+	g.names[t] = name
+	g.schemas[name] = &OpenApiSchema{}
+	*g.schemas[name] = *g.structSchema(t)
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *OpenApiSchema {
+	s := &OpenApiSchema{Type: "object", Properties: map[string]*OpenApiSchema{}}
+	g.addProperties(s, t)
+	return s
+}
+
+func (g *schemaGenerator) addProperties(s *OpenApiSchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// Parameter fields are bound by HttpCtx.BindParams and documented as parameters
+		if !field.IsExported() || isParamField(field) {
+			continue
+		}
+		// Form bodies are decoded by gorilla/schema, which uses the `schema` tag
+		tag, exists := field.Tag.Lookup("json")
+		if !exists {
+			tag = field.Tag.Get("schema")
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		// Embedded structs without json name are flattened like encoding/json does
+		if field.Anonymous && name == "" {
+			if ft := derefType(field.Type); ft.Kind() == reflect.Struct {
+				g.addProperties(s, ft)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.schema(field.Type)
+		if rules, exists := field.Tag.Lookup(validation.TAG); exists {
+			if applyRules(property, rules) {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = property
+	}
+}
+
+// applyRules adds the constraints of the `validate` tag to the schema and returns, whether
+// the value is required. Referenced schemas must not have siblings and stay unchanged.
+func applyRules(s *OpenApiSchema, tag string) bool {
+	required := false
+	for _, rule := range validation.ParseRules(tag) {
+		name, param := rule.Name, rule.Param
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "len":
+			if s.Ref == "" {
+				applyBound(s, name, param)
+			}
+		case "enum":
+			if s.Ref == "" {
+				for _, value := range strings.Split(param, "|") {
+					s.Enum = append(s.Enum, enumValue(s.Type, value))
+				}
+			}
+		case "regex":
+			if s.Ref == "" {
+				s.Pattern = param
+			}
+		}
+	}
+	return required
+}
+
+func applyBound(s *OpenApiSchema, rule string, param string) {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+	length := int(bound)
+	switch s.Type {
+	case "integer", "number":
+		if rule != "max" {
+			s.Minimum = &bound
+		}
+		if rule != "min" {
+			s.Maximum = &bound
+		}
+	case "string":
+		if rule != "max" {
+			s.MinLength = &length
+		}
+		if rule != "min" {
+			s.MaxLength = &length
+		}
+	case "array":
+		if rule != "max" {
+			s.MinItems = &length
+		}
+		if rule != "min" {
+			s.MaxItems = &length
+		}
+	case "object":
+		if rule != "max" {
+			s.MinProperties = &length
+		}
+		if rule != "min" {
+			s.MaxProperties = &length
+		}
+	}
+}
+
+func enumValue(schemaType string, value string) any {
+	switch schemaType {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: map[string]*OpenApiSchema{},
+		names:   map[reflect.Type]string{},
+	}
+}
@@ -0,0 +1,35 @@
+package servemux
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type SchemaTestBase struct {
+	TraceId string `header:"X-Trace-Id"`
+	Note    string `json:"note"`
+}
+
+type schemaTestBody struct {
+	SchemaTestBase
+	Id    int    `path:"id"`
+	Limit int    `query:"limit"`
+	Name  string `json:"name" validate:"required"`
+}
+
+func TestStructSchemaSkipsParamFields(t *testing.T) {
+	g := &schemaGenerator{schemas: map[string]*OpenApiSchema{}, names: map[reflect.Type]string{}}
+	s := g.structSchema(reflect.TypeFor[schemaTestBody]())
+	properties := []string{}
+	for name := range s.Properties {
+		properties = append(properties, name)
+	}
+	slices.Sort(properties)
+	if !slices.Equal(properties, []string{"name", "note"}) {
+		t.Errorf("properties = %v, want [name note]", properties)
+	}
+	if !slices.Equal(s.Required, []string{"name"}) {
+		t.Errorf("required = %v, want [name]", s.Required)
+	}
+}
@@ -0,0 +1,100 @@
+package servemux
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/uoul/go-common/serialization"
+	"github.com/uoul/go-common/validation"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------------------------------
+
+const (
+	MIME_PROBLEM_JSON = "application/problem+json"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// ProblemDetails is the body of an error response according to RFC 7807
+type ProblemDetails struct {
+	Type     string                  `json:"type,omitempty"`
+	Title    string                  `json:"title"`
+	Status   int                     `json:"status"`
+	Detail   string                  `json:"detail,omitempty"`
+	Instance string                  `json:"instance,omitempty"`
+	Errors   []validation.FieldError `json:"errors,omitempty"`
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// newProblemDetails creates the problem for the given errors. The status is taken from the
+// first IHttpError, then from the given status if it is an error status, otherwise 500.
+// Only details of errors choosing their status (IHttpError, validation errors) are shown.
+// Without such errors, details are shown for client errors and hidden for server errors.
+func newProblemDetails(req *http.Request, status int, errs []error) ProblemDetails {
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+	explicit := []error{}
+	for _, err := range errs {
+		var httpErr IHttpError
+		var validationErr *validation.ValidationError
+		switch {
+		case errors.As(err, &httpErr):
+			if len(explicit) == 0 {
+				status = httpErr.StatusCode()
+			}
+			explicit = append(explicit, err)
+		case errors.As(err, &validationErr):
+			if len(explicit) == 0 {
+				status = http.StatusBadRequest
+			}
+			explicit = append(explicit, err)
+		}
+	}
+	problem := ProblemDetails{
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: req.URL.RequestURI(),
+	}
+	shown := explicit
+	if len(explicit) == 0 && status < http.StatusInternalServerError {
+		shown = errs
+	}
+	if len(shown) > 0 {
+		details := make([]string, len(shown))
+		for i, err := range shown {
+			details[i] = err.Error()
+			var validationErr *validation.ValidationError
+			if errors.As(err, &validationErr) {
+				problem.Errors = append(problem.Errors, validationErr.Errors...)
+			}
+			var bindingErr *BindingError
+			if errors.As(err, &bindingErr) {
+				problem.Errors = append(problem.Errors, bindingErr.Errors...)
+			}
+		}
+		problem.Detail = strings.Join(details, "; ")
+	}
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, problem ProblemDetails) {
+	body, err := serialization.NewJSONSerializer().Marshal(problem)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", MIME_PROBLEM_JSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	w.Write(body)
+}
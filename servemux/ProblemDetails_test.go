@@ -0,0 +1,37 @@
+package servemux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/uoul/go-common/validation"
+)
+
+func TestNewProblemDetails(t *testing.T) {
+	internal := fmt.Errorf("open /srv/data/secret.txt: permission denied")
+	tests := []struct {
+		name   string
+		status int
+		errs   []error
+		want   int
+		detail string
+		fields int
+	}{
+		{"internal error is hidden", http.StatusOK, []error{internal}, http.StatusInternalServerError, "", 0},
+		{"http error chooses status", http.StatusOK, []error{NewHttpError(http.StatusNotFound, "item not found")}, http.StatusNotFound, "item not found", 0},
+		{"internal error next to http error is hidden", http.StatusOK, []error{internal, NewHttpError(http.StatusConflict, "conflict")}, http.StatusConflict, "conflict", 0},
+		{"validation error is shown", http.StatusOK, []error{internal, validation.NewValidationError([]validation.FieldError{{Field: "name", Reason: "is required"}})}, http.StatusBadRequest, "validation failed - name: is required", 1},
+		{"client status shows plain errors", http.StatusBadRequest, []error{fmt.Errorf("bad input")}, http.StatusBadRequest, "bad input", 0},
+		{"server status hides plain errors", http.StatusBadGateway, []error{internal}, http.StatusBadGateway, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := newProblemDetails(httptest.NewRequest(http.MethodGet, "/items", nil), tt.status, tt.errs)
+			if problem.Status != tt.want || problem.Detail != tt.detail || len(problem.Errors) != tt.fields {
+				t.Errorf("got status %d, detail %q, %d field errors, want %d, %q, %d", problem.Status, problem.Detail, len(problem.Errors), tt.want, tt.detail, tt.fields)
+			}
+		})
+	}
+}
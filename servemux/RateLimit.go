@@ -0,0 +1,201 @@
+package servemux
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// RateLimitKeyFunc returns the key of the client, requests with an empty key are not limited
+type RateLimitKeyFunc func(ctx *HttpCtx[any]) string
+
+type RateLimitConfig struct {
+	keyFunc    RateLimitKeyFunc
+	store      IRateLimitStore
+	prefix     string
+	failClosed bool
+}
+
+// MemoryRateLimitStore keeps the decoded rate limit state in memory. Idle keys are removed
+// periodically.
+type MemoryRateLimitStore struct {
+	mux       sync.Mutex
+	entries   map[string]*memoryRateLimitEntry
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state   any
+	expires time.Time
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// Take implements IRateLimitStore.
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, algorithm IRateLimitAlgorithm) (RateLimitResult, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	now := s.now()
+	s.sweep(now)
+	entry, exists := s.entries[key]
+	if !exists || now.After(entry.expires) {
+		entry = &memoryRateLimitEntry{}
+		s.entries[key] = entry
+	}
+	state, result := algorithm.TakeState(entry.state, now)
+	entry.state = state
+	entry.expires = now.Add(algorithm.Ttl())
+	return result, nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// RateLimit returns a middleware, that limits the requests per client key (default: remote
+// IP) using the given algorithm. Denied requests are aborted with 429 and Retry-After,
+// all responses carry the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers. Store errors let requests pass, unless configured otherwise.
+func RateLimit(algorithm IRateLimitAlgorithm, opts ...func(*RateLimitConfig)) HandlerFunc[any] {
+	config := NewRateLimitConfig(opts...)
+	return func(ctx *HttpCtx[any]) {
+		key := config.keyFunc(ctx)
+		if key == "" {
+			return
+		}
+		result, err := config.store.Take(ctx.Context(), config.prefix+key, algorithm)
+		if err != nil {
+			if config.failClosed {
+				ctx.AbortWithError(WrapHttpError(http.StatusServiceUnavailable, err))
+			}
+			return
+		}
+		ctx.SetHeader("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.SetHeader("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.SetHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		ctx.SetHeader("RateLimit-Policy", algorithm.Policy())
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			ctx.SetHeader("Retry-After", strconv.Itoa(retryAfter))
+			ctx.AbortWithError(NewHttpError(http.StatusTooManyRequests, "rate limit exceeded, retry in %ds", retryAfter))
+		}
+	}
+}
+
+// RateLimitByIp uses the IP of the remote address as key. Behind a reverse proxy use
+// RateLimitByHeader with the header containing the client IP instead.
+func RateLimitByIp() RateLimitKeyFunc {
+	return func(ctx *HttpCtx[any]) string {
+		remoteAddr := ctx.GetRawRequest().RemoteAddr
+		if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+			return host
+		}
+		return remoteAddr
+	}
+}
+
+// RateLimitByHeader uses the value of the given header as key (e.g. X-Real-IP, X-Api-Key)
+func RateLimitByHeader(name string) RateLimitKeyFunc {
+	return func(ctx *HttpCtx[any]) string {
+		return ctx.GetRawRequest().Header.Get(name)
+	}
+}
+
+// RateLimitByIdentity uses the key of the identity stored by Authenticate. Requests
+// without identity are not limited, combine it with a limiter by IP if needed.
+func RateLimitByIdentity[I any](keyOf func(identity I) string) RateLimitKeyFunc {
+	return func(ctx *HttpCtx[any]) string {
+		identity, ok := GetIdentity[I](ctx)
+		if !ok {
+			return ""
+		}
+		return keyOf(identity)
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------------------------------
+
+func WithRateLimitKey(keyFunc RateLimitKeyFunc) func(*RateLimitConfig) {
+	return func(rc *RateLimitConfig) {
+		rc.keyFunc = keyFunc
+	}
+}
+
+// WithRateLimitStore sets the store (default: a new MemoryRateLimitStore per middleware)
+func WithRateLimitStore(store IRateLimitStore) func(*RateLimitConfig) {
+	return func(rc *RateLimitConfig) {
+		rc.store = store
+	}
+}
+
+// WithRateLimitPrefix prefixes all keys, so that several limiters can share a store
+func WithRateLimitPrefix(prefix string) func(*RateLimitConfig) {
+	return func(rc *RateLimitConfig) {
+		rc.prefix = prefix
+	}
+}
+
+// WithRateLimitFailClosed aborts requests with 503, if the store fails
+func WithRateLimitFailClosed() func(*RateLimitConfig) {
+	return func(rc *RateLimitConfig) {
+		rc.failClosed = true
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// sweep removes expired entries at most once per minute
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(time.Minute)
+	for key, entry := range s.entries {
+		if now.After(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewMemoryRateLimitStore() IRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: map[string]*memoryRateLimitEntry{},
+		now:     time.Now,
+	}
+}
+
+func NewRateLimitConfig(opts ...func(*RateLimitConfig)) *RateLimitConfig {
+	c := &RateLimitConfig{
+		keyFunc: RateLimitByIp(),
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.store == nil {
+		c.store = NewMemoryRateLimitStore()
+	}
+	return c
+}
@@ -0,0 +1,211 @@
+package servemux
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type RateLimitResult struct {
+	Allowed bool
+	// Limit is the maximum number of requests of the quota
+	Limit int
+	// Remaining is the number of requests left in the quota
+	Remaining int
+	// Reset is the time until the quota is fully available again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed (denied requests only)
+	RetryAfter time.Duration
+}
+
+// TokenBucket allows bursts up to the bucket size and refills tokens at a constant rate
+type TokenBucket struct {
+	rate  float64 // tokens per second
+	burst int
+}
+
+// TokenBucketState is the JSON encoded state of a key limited by a TokenBucket
+type TokenBucketState struct {
+	Tokens float64 `json:"tokens"`
+	Last   int64   `json:"last"` // Unix time in nanoseconds
+}
+
+// SlidingWindow allows limit requests per window. It weights the count of the previous
+// window by its overlap with the sliding window, so that bursts at window borders are
+// smoothed out.
+type SlidingWindow struct {
+	limit  int
+	window time.Duration
+}
+
+// SlidingWindowState is the JSON encoded state of a key limited by a SlidingWindow
+type SlidingWindowState struct {
+	Start    int64 `json:"start"` // Unix time in nanoseconds
+	Current  int   `json:"current"`
+	Previous int   `json:"previous"`
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// Take implements IRateLimitAlgorithm.
+func (tb *TokenBucket) Take(state []byte, now time.Time) ([]byte, RateLimitResult) {
+	s := tb.initialState(now)
+	decodeRateLimitState(state, s)
+	result := tb.take(s, now)
+	return encodeRateLimitState(s), result
+}
+
+// TakeState implements IRateLimitAlgorithm.
+func (tb *TokenBucket) TakeState(state any, now time.Time) (any, RateLimitResult) {
+	s, ok := state.(*TokenBucketState)
+	if !ok {
+		s = tb.initialState(now)
+	}
+	return s, tb.take(s, now)
+}
+
+// Ttl implements IRateLimitAlgorithm.
+func (tb *TokenBucket) Ttl() time.Duration {
+	return tb.duration(float64(tb.burst))
+}
+
+// Policy implements IRateLimitAlgorithm.
+func (tb *TokenBucket) Policy() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", tb.burst, int(math.Ceil(float64(tb.burst)/tb.rate)), tb.burst)
+}
+
+// Take implements IRateLimitAlgorithm.
+func (sw *SlidingWindow) Take(state []byte, now time.Time) ([]byte, RateLimitResult) {
+	s := &SlidingWindowState{Start: now.UnixNano()}
+	decodeRateLimitState(state, s)
+	result := sw.take(s, now)
+	return encodeRateLimitState(s), result
+}
+
+// TakeState implements IRateLimitAlgorithm.
+func (sw *SlidingWindow) TakeState(state any, now time.Time) (any, RateLimitResult) {
+	s, ok := state.(*SlidingWindowState)
+	if !ok {
+		s = &SlidingWindowState{Start: now.UnixNano()}
+	}
+	return s, sw.take(s, now)
+}
+
+// Ttl implements IRateLimitAlgorithm.
+func (sw *SlidingWindow) Ttl() time.Duration {
+	return 2 * sw.window
+}
+
+// Policy implements IRateLimitAlgorithm.
+func (sw *SlidingWindow) Policy() string {
+	return fmt.Sprintf("%d;w=%d", sw.limit, int(math.Ceil(sw.window.Seconds())))
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (tb *TokenBucket) initialState(now time.Time) *TokenBucketState {
+	return &TokenBucketState{Tokens: float64(tb.burst), Last: now.UnixNano()}
+}
+
+func (tb *TokenBucket) take(s *TokenBucketState, now time.Time) RateLimitResult {
+	// Refill
+	s.Tokens = math.Min(float64(tb.burst), s.Tokens+now.Sub(time.Unix(0, s.Last)).Seconds()*tb.rate)
+	s.Last = now.UnixNano()
+	result := RateLimitResult{Limit: tb.burst}
+	if s.Tokens >= 1 {
+		s.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = tb.duration(1 - s.Tokens)
+	}
+	result.Remaining = int(s.Tokens)
+	result.Reset = tb.duration(float64(tb.burst) - s.Tokens)
+	return result
+}
+
+func (tb *TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / tb.rate * float64(time.Second))
+}
+
+func (sw *SlidingWindow) take(s *SlidingWindowState, now time.Time) RateLimitResult {
+	// Move window
+	start := time.Unix(0, s.Start)
+	if elapsed := now.Sub(start); elapsed >= sw.window {
+		windows := elapsed / sw.window
+		s.Previous = 0
+		if windows == 1 {
+			s.Previous = s.Current
+		}
+		s.Current = 0
+		start = start.Add(windows * sw.window)
+		s.Start = start.UnixNano()
+	}
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(sw.window)
+	count := float64(s.Previous)*weight + float64(s.Current)
+	result := RateLimitResult{Limit: sw.limit, Reset: sw.window - elapsed}
+	if count+1 <= float64(sw.limit) {
+		s.Current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = sw.retryAfter(s, elapsed)
+	}
+	result.Remaining = max(0, sw.limit-int(math.Ceil(count)))
+	return result
+}
+
+// retryAfter returns the time until the weighted count allows one more request
+func (sw *SlidingWindow) retryAfter(s *SlidingWindowState, elapsed time.Duration) time.Duration {
+	free := float64(sw.limit - s.Current - 1)
+	if free < 0 || s.Previous == 0 {
+		// Current window is exhausted, wait for the next one
+		return sw.window - elapsed
+	}
+	// previous * (1 - (elapsed + t) / window) <= free
+	t := time.Duration((1-free/float64(s.Previous))*float64(sw.window)) - elapsed
+	return max(t, 0)
+}
+
+// decodeRateLimitState decodes the stored state into s, unknown or invalid states keep
+// the initial state
+func decodeRateLimitState(state []byte, s any) {
+	if len(state) > 0 {
+		json.Unmarshal(state, s)
+	}
+}
+
+func encodeRateLimitState(s any) []byte {
+	state, _ := json.Marshal(s)
+	return state
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+// NewTokenBucket creates a token bucket, that refills limit tokens per period and holds
+// at most burst tokens
+func NewTokenBucket(limit int, period time.Duration, burst int) IRateLimitAlgorithm {
+	return &TokenBucket{
+		rate:  float64(limit) / period.Seconds(),
+		burst: burst,
+	}
+}
+
+// NewSlidingWindow creates a sliding window, that allows limit requests per window
+func NewSlidingWindow(limit int, window time.Duration) IRateLimitAlgorithm {
+	return &SlidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
@@ -0,0 +1,114 @@
+package servemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type rateLimitStep struct {
+	at   time.Duration // Offset from the start
+	want RateLimitResult
+}
+
+func TestTokenBucket(t *testing.T) {
+	// 1 token per second, burst of 2
+	testRateLimitAlgorithm(t, NewTokenBucket(1, time.Second, 2), []rateLimitStep{
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+		{0, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 2 * time.Second, RetryAfter: time.Second}},
+		{500 * time.Millisecond, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 1500 * time.Millisecond, RetryAfter: 500 * time.Millisecond}},
+		{time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}},
+		// Refill is capped at the burst
+		{time.Minute, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}},
+	})
+}
+
+func TestSlidingWindow(t *testing.T) {
+	// 2 requests per 10 seconds
+	testRateLimitAlgorithm(t, NewSlidingWindow(2, 10*time.Second), []rateLimitStep{
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: 10 * time.Second}},
+		{0, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 10 * time.Second}},
+		{5 * time.Second, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 5 * time.Second, RetryAfter: 5 * time.Second}},
+		// The previous window still counts fully at the start of the next one
+		{10 * time.Second, RateLimitResult{Allowed: false, Limit: 2, Remaining: 0, Reset: 10 * time.Second, RetryAfter: 5 * time.Second}},
+		{15 * time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 0, Reset: 5 * time.Second}},
+		// Windows older than the previous one are dropped
+		{40 * time.Second, RateLimitResult{Allowed: true, Limit: 2, Remaining: 1, Reset: 10 * time.Second}},
+	})
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	now := start
+	store := NewMemoryRateLimitStore().(*MemoryRateLimitStore)
+	store.now = func() time.Time { return now }
+	handler := Handle(NewHandlerConfig(), RateLimit(NewTokenBucket(1, time.Second, 2), WithRateLimitStore(store)), func(ctx *HttpCtx[any]) {})
+	tests := []struct {
+		name       string
+		at         time.Duration
+		remoteAddr string
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{"first request", 0, "192.0.2.1:1234", http.StatusOK, "1", "1", ""},
+		{"burst used up", 0, "192.0.2.1:1234", http.StatusOK, "0", "2", ""},
+		{"limit exceeded", 0, "192.0.2.1:1234", http.StatusTooManyRequests, "0", "2", "1"},
+		{"other client", 0, "192.0.2.2:1234", http.StatusOK, "1", "1", ""},
+		{"retry after partial refill", 500 * time.Millisecond, "192.0.2.1:1234", http.StatusTooManyRequests, "0", "2", "1"},
+		{"refilled", time.Second, "192.0.2.1:1234", http.StatusOK, "0", "2", ""},
+		{"expired state", time.Hour, "192.0.2.1:1234", http.StatusOK, "1", "1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = start.Add(tt.at)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			for header, want := range map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": tt.remaining,
+				"RateLimit-Reset":     tt.reset,
+				"RateLimit-Policy":    "2;w=2;burst=2",
+				"Retry-After":         tt.retryAfter,
+			} {
+				if got := rec.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+	if len(store.entries) != 1 {
+		t.Errorf("expired entries were not removed, %d entries left", len(store.entries))
+	}
+}
+
+// -----------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------
+
+// testRateLimitAlgorithm runs the steps on the in-memory and on the encoded state
+func testRateLimitAlgorithm(t *testing.T, algorithm IRateLimitAlgorithm, steps []rateLimitStep) {
+	t.Helper()
+	start := time.Unix(1700000000, 0)
+	var state any
+	var encoded []byte
+	for i, step := range steps {
+		var result, encodedResult RateLimitResult
+		state, result = algorithm.TakeState(state, start.Add(step.at))
+		encoded, encodedResult = algorithm.Take(encoded, start.Add(step.at))
+		if result != step.want {
+			t.Errorf("step %d: got %+v, want %+v", i, result, step.want)
+		}
+		if encodedResult != result {
+			t.Errorf("step %d: encoded state got %+v, in-memory state %+v", i, encodedResult, result)
+		}
+	}
+}
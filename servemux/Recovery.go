@@ -0,0 +1,31 @@
+package servemux
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/uoul/go-common/log"
+)
+
+// Recovery returns a middleware, that recovers panics of the following handlers, logs
+// them with stack trace and answers with a 500 problem response
+func Recovery(logger log.ILogger) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Let net/http abort the response silently
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			req := ctx.GetRawRequest()
+			log.WithContext(logger, ctx.Context()).Errorf("recovered panic in handler (%s %s) - %v\n%s", req.Method, req.URL.Path, rec, debug.Stack())
+			ctx.SetStatusCode(http.StatusInternalServerError)
+			ctx.AbortWithError(fmt.Errorf("panic: %v", rec))
+		}()
+		ctx.Next()
+	}
+}
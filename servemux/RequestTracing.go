@@ -0,0 +1,96 @@
+package servemux
+
+import (
+	"github.com/uoul/go-common/tracing"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type RequestTracingConfig struct {
+	requestIdHeader string
+	generate        func() string
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// RequestTracing returns a middleware, that takes the request id from the X-Request-ID
+// header (or generates one) and continues the trace of the traceparent header (or starts
+// a new one). Both are stored in the request context (see package tracing) and echoed on
+// the response. HttpCtx.Logger picks them up automatically, to propagate them to messages
+// pass HttpCtx.Context() to RabbitMqMessenger.PublishContext.
+func RequestTracing(opts ...func(*RequestTracingConfig)) HandlerFunc[any] {
+	config := NewRequestTracingConfig(opts...)
+	return func(ctx *HttpCtx[any]) {
+		req := ctx.GetRawRequest()
+		requestId := req.Header.Get(config.requestIdHeader)
+		if !validRequestId(requestId) {
+			requestId = config.generate()
+		}
+		traceParent, err := tracing.ParseTraceParent(req.Header.Get(tracing.TRACEPARENT_HEADER))
+		if err != nil {
+			traceParent = tracing.NewTraceParent()
+		} else {
+			traceParent = traceParent.Child()
+		}
+		c := tracing.WithRequestId(ctx.Context(), requestId)
+		c = tracing.WithTraceParent(c, traceParent)
+		ctx.SetContext(c)
+		ctx.SetHeader(config.requestIdHeader, requestId)
+		ctx.SetHeader(tracing.TRACEPARENT_HEADER, traceParent.String())
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------------------------------
+
+// WithRequestIdHeader sets the header of the request id (default X-Request-ID)
+func WithRequestIdHeader(name string) func(*RequestTracingConfig) {
+	return func(rc *RequestTracingConfig) {
+		rc.requestIdHeader = name
+	}
+}
+
+// WithRequestIdGenerator sets the function generating missing request ids (default UUIDs)
+func WithRequestIdGenerator(generate func() string) func(*RequestTracingConfig) {
+	return func(rc *RequestTracingConfig) {
+		rc.generate = generate
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// validRequestId accepts printable ASCII ids up to 128 characters, so that ids from
+// clients cannot inject anything into logs or headers
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > 128 {
+		return false
+	}
+	for _, c := range requestId {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewRequestTracingConfig(opts ...func(*RequestTracingConfig)) *RequestTracingConfig {
+	c := &RequestTracingConfig{
+		requestIdHeader: tracing.REQUEST_ID_HEADER,
+		generate:        tracing.NewRequestId,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
@@ -0,0 +1,109 @@
+package servemux
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// RouteInfo holds the documentation of a route registered on a Router, which is used to
+// generate the OpenAPI document. It is returned by HandleMethod, Get, Post, ...
+type RouteInfo struct {
+	method string
+	path   string
+	config *HandlerConfig
+
+	summary     string
+	description string
+	operationId string
+	tags        []string
+	deprecated  bool
+	hidden      bool
+
+	bodyType   reflect.Type
+	paramTypes []reflect.Type
+	responses  []routeResponse
+}
+
+type routeResponse struct {
+	status      int
+	description string
+	bodyType    reflect.Type
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+func (ri *RouteInfo) Summary(summary string) *RouteInfo {
+	ri.summary = summary
+	return ri
+}
+
+func (ri *RouteInfo) Description(description string) *RouteInfo {
+	ri.description = description
+	return ri
+}
+
+func (ri *RouteInfo) OperationId(id string) *RouteInfo {
+	ri.operationId = id
+	return ri
+}
+
+func (ri *RouteInfo) Tags(tags ...string) *RouteInfo {
+	ri.tags = append(ri.tags, tags...)
+	return ri
+}
+
+func (ri *RouteInfo) Deprecated() *RouteInfo {
+	ri.deprecated = true
+	return ri
+}
+
+// Hidden excludes the route from the OpenAPI document
+func (ri *RouteInfo) Hidden() *RouteInfo {
+	ri.hidden = true
+	return ri
+}
+
+// Params documents the parameters of a struct, that is bound by HttpCtx.BindParams
+// (e.g. Params(ItemQuery{})).
+func (ri *RouteInfo) Params(params any) *RouteInfo {
+	if t := reflect.TypeOf(params); t != nil {
+		ri.paramTypes = append(ri.paramTypes, t)
+	}
+	return ri
+}
+
+// Response documents a response with the type of the given body (nil for responses
+// without body). The description defaults to the status text.
+func (ri *RouteInfo) Response(status int, body any, description string) *RouteInfo {
+	if description == "" {
+		description = http.StatusText(status)
+	}
+	ri.responses = append(ri.responses, routeResponse{
+		status:      status,
+		description: description,
+		bodyType:    reflect.TypeOf(body),
+	})
+	return ri
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func newRouteInfo(method string, path string, bodyType reflect.Type, config *HandlerConfig) *RouteInfo {
+	return &RouteInfo{
+		method:     method,
+		path:       path,
+		config:     config,
+		tags:       []string{},
+		bodyType:   bodyType,
+		paramTypes: []reflect.Type{},
+		responses:  []routeResponse{},
+	}
+}
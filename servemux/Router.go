@@ -0,0 +1,204 @@
+package servemux
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type Router struct {
+	RouterGroup
+
+	mux    *http.ServeMux
+	config *HandlerConfig
+	routes map[string]*route
+	infos  []*RouteInfo
+}
+
+type RouterGroup struct {
+	router     *Router
+	parent     *RouterGroup
+	prefix     string
+	middleware []HandlerFunc[any]
+}
+
+type route struct {
+	handlers map[string]http.HandlerFunc
+	// options answers OPTIONS requests without handler through the middleware of the
+	// first registered handler (e.g. for CORS preflight requests). Route handlers are
+	// not run.
+	options http.HandlerFunc
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// ServeHTTP implements http.Handler.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
+
+// Group implements IRouterGroup.
+func (g *RouterGroup) Group(prefix string, middleware ...HandlerFunc[any]) IRouterGroup {
+	return &RouterGroup{
+		router:     g.router,
+		parent:     g,
+		prefix:     joinPath(g.prefix, prefix),
+		middleware: middleware,
+	}
+}
+
+// Use implements IRouterGroup.
+func (g *RouterGroup) Use(middleware ...HandlerFunc[any]) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// HandleMethod registers handlers for the given method and pattern (e.g. "/items/{id}")
+// on the group. A GET route also answers HEAD requests. The returned RouteInfo documents
+// the route for the OpenAPI document, the request body is documented by T.
+func HandleMethod[T any](group IRouterGroup, method string, pattern string, handlers ...HandlerFunc[T]) *RouteInfo {
+	// Untyped handlers (HttpCtx[any]) have no documented request body
+	bodyType := reflect.TypeFor[T]()
+	if bodyType.Kind() == reflect.Interface {
+		bodyType = nil
+	}
+	return group.register(method, pattern, bodyType, func(config *HandlerConfig, middleware []HandlerFunc[any]) http.HandlerFunc {
+		return handle(config, middleware, handlers)
+	})
+}
+
+func Get[T any](group IRouterGroup, pattern string, handlers ...HandlerFunc[T]) *RouteInfo {
+	return HandleMethod(group, http.MethodGet, pattern, handlers...)
+}
+
+func Post[T any](group IRouterGroup, pattern string, handlers ...HandlerFunc[T]) *RouteInfo {
+	return HandleMethod(group, http.MethodPost, pattern, handlers...)
+}
+
+func Put[T any](group IRouterGroup, pattern string, handlers ...HandlerFunc[T]) *RouteInfo {
+	return HandleMethod(group, http.MethodPut, pattern, handlers...)
+}
+
+func Patch[T any](group IRouterGroup, pattern string, handlers ...HandlerFunc[T]) *RouteInfo {
+	return HandleMethod(group, http.MethodPatch, pattern, handlers...)
+}
+
+func Delete[T any](group IRouterGroup, pattern string, handlers ...HandlerFunc[T]) *RouteInfo {
+	return HandleMethod(group, http.MethodDelete, pattern, handlers...)
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (g *RouterGroup) register(method string, pattern string, bodyType reflect.Type, build func(config *HandlerConfig, middleware []HandlerFunc[any]) http.HandlerFunc) *RouteInfo {
+	method, path := strings.ToUpper(method), joinPath(g.prefix, pattern)
+	middleware := g.chain()
+	rt := g.router.addRoute(method, path, build(g.router.config, middleware))
+	if rt.options == nil {
+		rt.options = handle(g.router.config, middleware, []HandlerFunc[any]{rt.handleOptions})
+	}
+	info := newRouteInfo(method, path, bodyType, g.router.config)
+	g.router.infos = append(g.router.infos, info)
+	return info
+}
+
+// chain returns the middleware of the parent groups followed by the middleware of this
+// group, as registered at the time of the call
+func (g *RouterGroup) chain() []HandlerFunc[any] {
+	if g.parent == nil {
+		return slices.Clone(g.middleware)
+	}
+	return slices.Concat(g.parent.chain(), g.middleware)
+}
+
+func (r *Router) addRoute(method string, path string, handler http.HandlerFunc) *route {
+	rt, exists := r.routes[path]
+	if !exists {
+		rt = &route{handlers: map[string]http.HandlerFunc{}}
+		r.routes[path] = rt
+		r.mux.HandleFunc(path, rt.serveHTTP)
+	}
+	rt.handlers[method] = handler
+	return rt
+}
+
+func (rt *route) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if handler, exists := rt.handlers[req.Method]; exists {
+		handler(w, req)
+		return
+	}
+	// HEAD is answered by GET, the server discards the body
+	if handler, exists := rt.handlers[http.MethodGet]; exists && req.Method == http.MethodHead {
+		handler(w, req)
+		return
+	}
+	if req.Method == http.MethodOptions && rt.options != nil {
+		rt.options(w, req)
+		return
+	}
+	w.Header().Set("Allow", rt.allow())
+	err := NewHttpError(http.StatusMethodNotAllowed, "method %s is not allowed", req.Method)
+	writeProblem(w, newProblemDetails(req, http.StatusMethodNotAllowed, []error{err}))
+}
+
+func (rt *route) handleOptions(ctx *HttpCtx[any]) {
+	ctx.SetHeader("Allow", rt.allow())
+	ctx.SetStatusCode(http.StatusNoContent)
+}
+
+func (rt *route) allow() string {
+	methods := []string{http.MethodOptions}
+	for method := range rt.handlers {
+		methods = append(methods, method)
+	}
+	if _, exists := rt.handlers[http.MethodGet]; exists {
+		if _, exists := rt.handlers[http.MethodHead]; !exists {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	slices.Sort(methods)
+	return strings.Join(slices.Compact(methods), ", ")
+}
+
+func joinPath(prefix string, path string) string {
+	if prefix == "" {
+		return path
+	}
+	if path == "" {
+		return prefix
+	}
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewRouter(config *HandlerConfig, middleware ...HandlerFunc[any]) *Router {
+	if config == nil {
+		config = NewHandlerConfig()
+	}
+	r := &Router{
+		mux:    http.NewServeMux(),
+		config: config,
+		routes: map[string]*route{},
+		infos:  []*RouteInfo{},
+	}
+	r.RouterGroup = RouterGroup{
+		router:     r,
+		prefix:     "",
+		middleware: middleware,
+	}
+	return r
+}
@@ -0,0 +1,317 @@
+package servemux
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uoul/go-common/health"
+	"github.com/uoul/go-common/log"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type Server struct {
+	logger  log.ILogger
+	server  *http.Server
+	handler http.Handler
+
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	tlsConfig       *tls.Config
+	certificate     *certificateReloader
+	healthMonitor   *health.HealthMonitor
+	healthPrefix    string
+
+	mux      sync.Mutex
+	listener net.Listener
+	ready    bool
+	shutdown chan struct{}
+}
+
+// certificateReloader loads the certificate again, when the files change on disk (e.g.
+// renewed certificates mounted from a secret)
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mux         sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time
+	nextCheck   time.Time
+}
+
+// serverShutdownKey stores the shutdown channel of the server in request contexts, so that
+// hijacked connections (e.g. websockets), which are not drained by http.Server.Shutdown,
+// can be closed
+type serverShutdownKey struct{}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// Start implements IServer.
+func (s *Server) Start() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.listener != nil {
+		return fmt.Errorf("server is already started")
+	}
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return err
+	}
+	if s.server.TLSConfig != nil {
+		listener = tls.NewListener(listener, s.server.TLSConfig)
+	}
+	s.listener = listener
+	s.ready = true
+	go func() {
+		s.logger.Infof("http server listening on %s", listener.Addr())
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf("http server on %s failed - %v", listener.Addr(), err)
+			s.setReady(false)
+		}
+	}()
+	return nil
+}
+
+// Addr implements IServer.
+func (s *Server) Addr() string {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.listener == nil {
+		return s.server.Addr
+	}
+	return s.listener.Addr().String()
+}
+
+// ReadinessCheck implements IServer.
+func (s *Server) ReadinessCheck() error {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if !s.ready {
+		return fmt.Errorf("http server on %s is not ready", s.server.Addr)
+	}
+	return nil
+}
+
+// Close implements resource.IResource. The server reports not ready first, waits for the
+// shutdown delay, so that load balancers stop sending requests, and drains open connections
+// until the shutdown timeout elapses. Remaining connections are closed forcibly.
+func (s *Server) Close() error {
+	s.setReady(false)
+	time.Sleep(s.shutdownDelay)
+	s.mux.Lock()
+	select {
+	case <-s.shutdown:
+	default:
+		close(s.shutdown)
+	}
+	s.mux.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.logger.Warningf("http server on %s did not drain connections in time - %v", s.Addr(), err)
+		return s.server.Close()
+	}
+	s.logger.Infof("http server on %s stopped", s.Addr())
+	return nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------------------------------
+
+// WithServerTimeouts sets read, write and idle timeout (default 30s, 60s and 120s). Streamed
+// responses (server-sent events, websockets, ...) are not limited by the write timeout.
+func WithServerTimeouts(read time.Duration, write time.Duration, idle time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.server.ReadTimeout = read
+		s.server.WriteTimeout = write
+		s.server.IdleTimeout = idle
+	}
+}
+
+// WithServerReadHeaderTimeout sets the timeout for reading request headers (default 10s)
+func WithServerReadHeaderTimeout(timeout time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.server.ReadHeaderTimeout = timeout
+	}
+}
+
+// WithServerMaxHeaderBytes limits the size of request headers (default 1MB)
+func WithServerMaxHeaderBytes(size int) func(*Server) {
+	return func(s *Server) {
+		s.server.MaxHeaderBytes = size
+	}
+}
+
+// WithServerShutdownTimeout sets how long open connections are drained on Close (default 30s)
+func WithServerShutdownTimeout(timeout time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// WithServerShutdownDelay sets how long the server keeps serving after reporting not
+// ready on Close (default 0)
+func WithServerShutdownDelay(delay time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.shutdownDelay = delay
+	}
+}
+
+// WithServerTls serves TLS with the certificate of the given files. Changed files are
+// loaded again on new connections, checked at most once per reload interval.
+func WithServerTls(certFile string, keyFile string, reloadInterval time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.certificate = &certificateReloader{
+			certFile: certFile,
+			keyFile:  keyFile,
+			interval: reloadInterval,
+		}
+	}
+}
+
+// WithServerTlsConfig sets the TLS configuration (e.g. client authentication). If combined
+// with WithServerTls, the certificate is provided by the reloading loader.
+func WithServerTlsConfig(config *tls.Config) func(*Server) {
+	return func(s *Server) {
+		s.tlsConfig = config
+	}
+}
+
+// WithServerHealthMonitor registers the readiness of the server at the monitor and serves
+// its probes under the given prefix (e.g. "/health")
+func WithServerHealthMonitor(monitor *health.HealthMonitor, prefix string) func(*Server) {
+	return func(s *Server) {
+		s.healthMonitor = monitor
+		s.healthPrefix = prefix
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (s *Server) setReady(ready bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.ready = ready
+}
+
+func (c *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	now := time.Now()
+	if c.certificate != nil && now.Before(c.nextCheck) {
+		return c.certificate, nil
+	}
+	c.nextCheck = now.Add(c.interval)
+	if err := c.load(); err != nil {
+		if c.certificate == nil {
+			return nil, err
+		}
+		// Keep serving the last valid certificate
+	}
+	return c.certificate, nil
+}
+
+// load reads the certificate, if the files changed since the last load
+func (c *certificateReloader) load() error {
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return err
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return err
+	}
+	modTime := certInfo.ModTime()
+	if keyInfo.ModTime().After(modTime) {
+		modTime = keyInfo.ModTime()
+	}
+	if c.certificate != nil && modTime.Equal(c.modTime) {
+		return nil
+	}
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.certificate = &certificate
+	c.modTime = modTime
+	return nil
+}
+
+// serverShutdown returns a channel, that is closed when the server serving the request
+// shuts down (nil for requests not served by Server)
+func serverShutdown(ctx context.Context) <-chan struct{} {
+	shutdown, _ := ctx.Value(serverShutdownKey{}).(chan struct{})
+	return shutdown
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+// NewServer creates a server for the handler (e.g. a Router) listening on addr. Register it
+// at a resource.IResourceManager for graceful shutdown.
+func NewServer(logger log.ILogger, addr string, handler http.Handler, opts ...func(*Server)) (IServer, error) {
+	s := &Server{
+		logger:  logger,
+		handler: handler,
+		server: &http.Server{
+			Addr:              addr,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    1 << 20, // 1MB
+		},
+		shutdownTimeout: 30 * time.Second,
+		shutdown:        make(chan struct{}),
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	// Handler
+	if s.healthMonitor != nil {
+		s.healthMonitor.RegisterReadynessCheck(fmt.Sprintf("http-server %s", addr), s.ReadinessCheck)
+		mux := http.NewServeMux()
+		s.healthMonitor.RegisterEndpointsDefault(mux, s.healthPrefix)
+		mux.Handle("/", s.handler)
+		s.handler = mux
+	}
+	s.server.Handler = s.handler
+	s.server.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), serverShutdownKey{}, s.shutdown)
+	}
+	// TLS
+	if s.certificate != nil {
+		if err := s.certificate.load(); err != nil {
+			return nil, fmt.Errorf("failed to load tls certificate - %v", err)
+		}
+		if s.tlsConfig == nil {
+			s.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		s.tlsConfig = s.tlsConfig.Clone()
+		s.tlsConfig.GetCertificate = s.certificate.getCertificate
+	}
+	if s.tlsConfig != nil {
+		s.server.TLSConfig = s.tlsConfig.Clone()
+		if len(s.server.TLSConfig.NextProtos) == 0 {
+			s.server.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+		}
+	}
+	return s, nil
+}
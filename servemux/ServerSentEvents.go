@@ -0,0 +1,208 @@
+package servemux
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/uoul/go-common/async"
+	"github.com/uoul/go-common/serialization"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type ServerSentEvent struct {
+	ID    string        // Event id (sent back by clients as Last-Event-ID on reconnect)
+	Event string        // Event type (e.g. "message")
+	Data  any           // Data (will be serialized using given serializer, multiple lines are split into multiple data fields)
+	Retry time.Duration // Reconnection time for clients (optional)
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// GetLastEventId returns the id of the last event a reconnecting client received
+func (h *HttpCtx[T]) GetLastEventId() string {
+	return h.req.Header.Get("Last-Event-ID")
+}
+
+// Stream sends the events returned by step as server-sent events, until step returns false
+// or the client disconnects (first return value true). Step is called on the handler
+// goroutine and no heartbeats are sent, use StreamEvents to send heartbeats while waiting
+// for events.
+func (h *HttpCtx[T]) Stream(step func() (ServerSentEvent, bool)) (bool, error) {
+	rc, err := h.beginEventStream()
+	if err != nil {
+		return false, err
+	}
+	// Run Stream
+	for {
+		select {
+		case <-h.Context().Done():
+			return true, nil
+		default:
+			msg, proceed := step()
+			if !proceed {
+				return false, nil
+			}
+			sse, err := msg.marshal(h.respSerializer)
+			if err != nil {
+				return false, err
+			}
+			if err := h.writeEvent(rc, sse); err != nil {
+				return false, err
+			}
+		}
+	}
+}
+
+// StreamEvents sends all events received from the channel as server-sent events, until the
+// channel is closed or the client disconnects (first return value true). A heartbeat
+// comment is sent, if no event was sent within the configured heartbeat interval. Nothing
+// is written by the handler chain after the stream.
+func (h *HttpCtx[T]) StreamEvents(events <-chan ServerSentEvent) (bool, error) {
+	rc, err := h.beginEventStream()
+	if err != nil {
+		return false, err
+	}
+	// Heartbeat
+	var heartbeat <-chan time.Time
+	if h.sseHeartbeat > 0 {
+		ticker := time.NewTicker(h.sseHeartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	// Run Stream
+	for {
+		var data []byte
+		select {
+		case <-h.Context().Done():
+			return true, nil
+		case <-heartbeat:
+			data = []byte(": heartbeat\n\n")
+		case msg, ok := <-events:
+			if !ok {
+				return false, nil
+			}
+			sse, err := msg.marshal(h.respSerializer)
+			if err != nil {
+				return false, err
+			}
+			data = sse
+		}
+		if err := h.writeEvent(rc, data); err != nil {
+			return false, err
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// StreamSSE sends each result of the stream as server-sent event created by toEvent, until
+// the stream is closed or the client disconnects (first return value true). An error result
+// is sent as event of type "error" and ends the stream.
+func StreamSSE[I any, T any](ctx *HttpCtx[T], stream async.Stream[I], toEvent func(item I) ServerSentEvent) (bool, error) {
+	events := make(chan ServerSentEvent)
+	stop := make(chan struct{})
+	defer close(stop)
+	var streamErr error
+	go func() {
+		defer close(events)
+		for {
+			var event ServerSentEvent
+			select {
+			case <-stop:
+				return
+			case item, ok := <-stream:
+				if !ok {
+					return
+				}
+				if item.Error != nil {
+					streamErr = item.Error
+					event = ServerSentEvent{Event: "error", Data: item.Error.Error()}
+				} else {
+					event = toEvent(item.Result)
+				}
+			}
+			select {
+			case events <- event:
+			case <-stop:
+				return
+			}
+			if streamErr != nil {
+				return
+			}
+		}
+	}()
+	disconnected, err := ctx.StreamEvents(events)
+	if err != nil {
+		return disconnected, err
+	}
+	// Stream goroutine has finished, if events was closed
+	if !disconnected {
+		return false, streamErr
+	}
+	return true, nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// beginEventStream writes the headers of the event stream. Nothing is written by the
+// handler chain afterwards.
+func (h *HttpCtx[T]) beginEventStream() (*http.ResponseController, error) {
+	h.startStream()
+	h.respWriter.Header().Set("Content-Type", "text/event-stream")
+	h.respWriter.Header().Set("Cache-Control", "no-cache")
+	h.respWriter.Header().Set("X-Accel-Buffering", "no")
+	h.respWriter.WriteHeader(h.statusCode)
+	rc := http.NewResponseController(h.respWriter)
+	if err := rc.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to flush event stream - %v", err)
+	}
+	return rc, nil
+}
+
+func (h *HttpCtx[T]) writeEvent(rc *http.ResponseController, data []byte) error {
+	if _, err := h.respWriter.Write(data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+func (s *ServerSentEvent) marshal(serializer serialization.ISerializer) ([]byte, error) {
+	b := &bytes.Buffer{}
+	if s.ID != "" {
+		fmt.Fprintf(b, "id: %s\n", singleLine(s.ID))
+	}
+	if s.Event != "" {
+		fmt.Fprintf(b, "event: %s\n", singleLine(s.Event))
+	}
+	if s.Retry > 0 {
+		fmt.Fprintf(b, "retry: %d\n", s.Retry.Milliseconds())
+	}
+	if s.Data != nil {
+		data, err := serializer.Marshal(s.Data)
+		if err != nil {
+			return nil, err
+		}
+		lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+		for _, line := range lines {
+			fmt.Fprintf(b, "data: %s\n", line)
+		}
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,124 @@
+package servemux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/uoul/go-common/async"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// SetAttachment sets the Content-Disposition header, so that clients download the response
+// as file with the given name
+func (h *HttpCtx[T]) SetAttachment(filename string) {
+	h.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+}
+
+// WriteReader streams the content of r as response with the current status code. If size
+// is not negative, it is sent as Content-Length. The response body and errors set on the
+// context are not written afterwards.
+func (h *HttpCtx[T]) WriteReader(contentType string, size int64, r io.Reader) error {
+	h.startStream()
+	if contentType != "" {
+		h.SetHeader("Content-Type", contentType)
+	}
+	if size >= 0 {
+		h.SetHeader("Content-Length", strconv.FormatInt(size, 10))
+	}
+	h.respWriter.WriteHeader(h.statusCode)
+	_, err := io.Copy(h.respWriter, r)
+	return err
+}
+
+// ServeContent serves content with support for Range requests and conditional requests
+// (If-Modified-Since, If-None-Match with an ETag header set before). The content type is
+// derived from the name, if not set.
+func (h *HttpCtx[T]) ServeContent(name string, modTime time.Time, content io.ReadSeeker) {
+	h.startStream()
+	http.ServeContent(h.respWriter, h.req, name, modTime, content)
+}
+
+// ServeFile serves the file at the given path like ServeContent and sets a weak ETag
+// derived from size and modification time. Missing files and directories return a 404
+// IHttpError naming only the file, other errors are returned as is. Like GetBody, errors
+// are not recorded on the context, pass them to AbortWithError.
+func (h *HttpCtx[T]) ServeFile(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return NewHttpError(http.StatusNotFound, "file %s not found", filepath.Base(path))
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return NewHttpError(http.StatusNotFound, "%s is a directory", filepath.Base(path))
+	}
+	if h.respWriter.Header().Get("ETag") == "" {
+		h.SetHeader("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+	}
+	h.ServeContent(info.Name(), info.ModTime(), f)
+	return nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// StreamNDJSON writes each result of the stream as JSON line (application/x-ndjson) and
+// flushes it, until the stream is closed or the request is canceled. An error result ends
+// the response and is returned, because the status has already been sent.
+func StreamNDJSON[I any, T any](ctx *HttpCtx[T], stream async.Stream[I]) error {
+	ctx.startStream()
+	ctx.SetHeader("Content-Type", "application/x-ndjson")
+	ctx.SetHeader("Cache-Control", "no-cache")
+	ctx.respWriter.WriteHeader(ctx.statusCode)
+	rc := http.NewResponseController(ctx.respWriter)
+	rc.Flush()
+	encoder := json.NewEncoder(ctx.respWriter)
+	for {
+		select {
+		case <-ctx.Context().Done():
+			return nil
+		case item, ok := <-stream:
+			if !ok {
+				return nil
+			}
+			if item.Error != nil {
+				return item.Error
+			}
+			if err := encoder.Encode(item.Result); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+// startStream marks the response as written, so that the handler chain does not write
+// status or body after a streamed response. Long running streams are not limited by the
+// write timeout of the server.
+func (h *httpCtxState) startStream() {
+	h.written = true
+	http.NewResponseController(h.respWriter).SetWriteDeadline(time.Time{})
+}
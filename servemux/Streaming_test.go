@@ -0,0 +1,45 @@
+package servemux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+	}{
+		{"existing file", filepath.Join(dir, "data.txt"), http.StatusOK, "content"},
+		{"missing file", filepath.Join(dir, "missing.txt"), http.StatusNotFound, "file missing.txt not found"},
+		{"directory", dir, http.StatusNotFound, "is a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Handle(NewHandlerConfig(), func(ctx *HttpCtx[any]) {
+				if err := ctx.ServeFile(tt.path); err != nil {
+					ctx.AbortWithError(err)
+				}
+			})
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.body) {
+				t.Errorf("got %d %q, want %d containing %q", rec.Code, rec.Body.String(), tt.status, tt.body)
+			}
+			// Server paths must not be exposed
+			if strings.Contains(rec.Body.String(), dir) {
+				t.Errorf("response exposes server path: %s", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package servemux
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/uoul/go-common/validation"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// TypedHandlerFunc handles a decoded request and returns the response body or an error,
+// which is rendered as problem response
+type TypedHandlerFunc[TReq any, TResp any] func(ctx *HttpCtx[TReq], req TReq) (TResp, error)
+
+// NoContent can be used as response type of typed handlers without response body. The
+// response is sent with 204, unless the handler sets another status.
+type NoContent struct{}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// Typed adapts a typed handler into a HandlerFunc. The request is decoded from the body
+// (see HttpCtx.GetBody), if the request has one or is a POST, PUT or PATCH request without
+// parameters, and from path, query and header parameters (see HttpCtx.BindParams), if
+// TReq has tagged fields. The request is validated once after decoding, if enabled.
+//
+// Decoding errors abort with 400. This includes POST, PUT and PATCH requests with an empty
+// body, if TReq has no parameter fields. Returned errors choose the status like errors
+// passed to HttpCtx.Error, in addition context.DeadlineExceeded is mapped to 504 without
+// exposing the error.
+func Typed[TReq any, TResp any](handler TypedHandlerFunc[TReq, TResp]) HandlerFunc[TReq] {
+	decodeBody := reflect.TypeFor[TReq]().Kind() != reflect.Interface
+	bindParams := hasParamFields(reflect.TypeFor[TReq]())
+	return func(ctx *HttpCtx[TReq]) {
+		req := *new(TReq)
+		decoded := false
+		if decodeBody && (hasBody(ctx.GetRawRequest()) || (requiresBody(ctx.GetRawRequest()) && !bindParams)) {
+			// Validated after binding parameters, so that rules of parameter fields see bound values
+			body, err := ctx.decodeBody()
+			if err != nil {
+				ctx.AbortWithError(badRequest(err))
+				return
+			}
+			req, decoded = body, true
+		}
+		if bindParams {
+			// Validates the whole request, if enabled
+			if err := ctx.BindParams(&req); err != nil {
+				ctx.AbortWithError(badRequest(err))
+				return
+			}
+		} else if decoded && ctx.validate {
+			if err := validation.Validate(&req); err != nil {
+				ctx.AbortWithError(badRequest(err))
+				return
+			}
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			ctx.AbortWithError(mapError(err))
+			return
+		}
+		if _, ok := any(resp).(NoContent); ok {
+			if ctx.GetStatusCode() == http.StatusOK {
+				ctx.SetStatusCode(http.StatusNoContent)
+			}
+			return
+		}
+		ctx.SetResponseBody(resp)
+	}
+}
+
+// HandleTyped returns a http.HandlerFunc for the typed handler (see Typed)
+func HandleTyped[TReq any, TResp any](config *HandlerConfig, handler TypedHandlerFunc[TReq, TResp]) http.HandlerFunc {
+	return Handle(config, Typed(handler))
+}
+
+// HandleTypedMethod registers the typed handler (see Typed) for the given method and
+// pattern on the group. Parameters of TReq and the response type (as 200, or 204 for
+// NoContent) are documented in the OpenAPI document.
+func HandleTypedMethod[TReq any, TResp any](group IRouterGroup, method string, pattern string, handler TypedHandlerFunc[TReq, TResp]) *RouteInfo {
+	info := HandleMethod(group, method, pattern, Typed(handler))
+	if hasParamFields(reflect.TypeFor[TReq]()) {
+		info.Params(*new(TReq))
+	}
+	if reflect.TypeFor[TResp]() == reflect.TypeFor[NoContent]() {
+		return info.Response(http.StatusNoContent, nil, "")
+	}
+	return info.Response(http.StatusOK, *new(TResp), "")
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private Functions
+// -----------------------------------------------------------------------------------------------------------
+
+func hasBody(req *http.Request) bool {
+	return req.ContentLength != 0 || len(req.TransferEncoding) > 0
+}
+
+func requiresBody(req *http.Request) bool {
+	return req.Method == http.MethodPost || req.Method == http.MethodPut || req.Method == http.MethodPatch
+}
+
+// badRequest marks decoding errors, that do not choose their status, as client errors
+func badRequest(err error) error {
+	var httpErr IHttpError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+	return WrapHttpError(http.StatusBadRequest, err)
+}
+
+func mapError(err error) error {
+	var httpErr IHttpError
+	switch {
+	case errors.As(err, &httpErr):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return NewHttpError(http.StatusGatewayTimeout, "request timed out")
+	default:
+		return err
+	}
+}
+
+// hasParamFields checks whether the struct has fields bound by HttpCtx.BindParams
+func hasParamFields(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag == "" && hasParamFields(field.Type) {
+			return true
+		}
+		for _, tag := range []string{"path", "query", "header"} {
+			if _, exists := field.Tag.Lookup(tag); exists {
+				return true
+			}
+		}
+	}
+	return false
+}
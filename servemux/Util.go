@@ -15,23 +15,97 @@ type HandlerFunc[T any] func(ctx *HttpCtx[T])
 // -----------------------------------------------------------------------------------------------------------
 
 func Handle[T any](config *HandlerConfig, handlers ...HandlerFunc[T]) http.HandlerFunc {
+	return handle(config, nil, handlers)
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private Functions
+// -----------------------------------------------------------------------------------------------------------
+
+func handle[T any](config *HandlerConfig, middleware []HandlerFunc[any], handlers []HandlerFunc[T]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Limit request body
+		if config.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, config.maxBodySize)
+		}
 		// Create HttpCtx
 		httpCtx := NewHttpCtx[T](r, w, config.serializer, config.maxMemSize)
-		// Call handlers
-		for _, handler := range handlers {
-			handler(httpCtx)
-			if httpCtx.aborted {
-				break
+		httpCtx.validate = config.validate
+		httpCtx.disallowUnknownFields = config.disallowUnknownFields
+		httpCtx.maxFileSize = config.maxFileSize
+		httpCtx.sseHeartbeat = config.sseHeartbeat
+		httpCtx.logger = config.logger
+		if config.negotiate {
+			httpCtx.negotiate(config)
+			w.Header().Add("Vary", "Accept")
+		}
+		// Build handler chain: middleware, negotiation check, handlers
+		middlewareCtx := convertHttpCtx[T, any](httpCtx)
+		chain := make([]func(), 0, len(middleware)+len(handlers)+1)
+		for _, m := range middleware {
+			chain = append(chain, func() { m(middlewareCtx) })
+		}
+		chain = append(chain, func() {
+			// Reject unsupported request bodies before calling handlers
+			if httpCtx.unsupportedMediaType {
+				httpCtx.AbortWithError(NewHttpError(http.StatusUnsupportedMediaType, "unsupported content type %s", r.Header.Get("Content-Type")))
 			}
+		})
+		for _, handler := range handlers {
+			chain = append(chain, func() { handler(httpCtx) })
 		}
-		// Parse Response body
-		respBody, _ := config.serializer.Marshal(
-			httpCtx.GetResponseBody(),
-		)
-		// Write ResultHeader
-		w.WriteHeader(httpCtx.GetStatusCode())
-		// Write Result
-		w.Write(respBody)
+		httpCtx.chain = chain
+		// Run chain, the response is written after the last handler
+		httpCtx.Next()
+	}
+}
+
+// next runs the remaining handlers of the chain and writes the response afterwards
+func (h *httpCtxState) next() {
+	h.index++
+	for ; h.index < len(h.chain) && !h.aborted; h.index++ {
+		h.chain[h.index]()
+	}
+	h.writeResponse()
+}
+
+// writeResponse writes status and response body once
+func (h *httpCtxState) writeResponse() {
+	if h.written {
+		return
+	}
+	h.written = true
+	w, r := h.respWriter, h.req
+	// Render collected errors as problem details
+	if len(h.errors) > 0 {
+		writeProblem(w, newProblemDetails(r, h.statusCode, h.errors))
+		return
+	}
+	// No acceptable representation for successful responses
+	if h.notAcceptable && h.statusCode < http.StatusBadRequest {
+		writeProblem(w, newProblemDetails(r, http.StatusNotAcceptable, []error{
+			NewHttpError(http.StatusNotAcceptable, "no acceptable representation for %s", r.Header.Get("Accept")),
+		}))
+		return
+	}
+	// Responses without body (e.g. 204 No Content)
+	if h.statusCode == http.StatusNoContent || h.statusCode == http.StatusNotModified {
+		w.WriteHeader(h.statusCode)
+		return
+	}
+	// Parse Response body
+	respBody, err := h.respSerializer.Marshal(
+		h.responseBody,
+	)
+	if err != nil {
+		writeProblem(w, newProblemDetails(r, http.StatusInternalServerError, []error{err}))
+		return
+	}
+	if h.respContentType != "" && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", h.respContentType)
 	}
+	// Write ResultHeader
+	w.WriteHeader(h.statusCode)
+	// Write Result
+	w.Write(respBody)
 }
@@ -0,0 +1,65 @@
+package servemux
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// Key identifies a value of type V in the per-request store of HttpCtx. Keys are compared
+// by identity, so create them once (e.g. as package variable) with NewKey.
+type Key[V any] struct {
+	name string
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// String returns the name of the key
+func (k *Key[V]) String() string {
+	return k.name
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// SetValue stores the value for the key, so that following handlers of the chain (with
+// any body type) can read it with GetValue
+func SetValue[V any, T any](ctx *HttpCtx[T], key *Key[V], value V) {
+	if ctx.values == nil {
+		ctx.values = map[any]any{}
+	}
+	ctx.values[key] = value
+}
+
+// GetValue returns the value stored for the key and whether it exists
+func GetValue[V any, T any](ctx *HttpCtx[T], key *Key[V]) (V, bool) {
+	value, exists := ctx.values[key]
+	if !exists {
+		return *new(V), false
+	}
+	return value.(V), true
+}
+
+// MustGetValue returns the value stored for the key and panics if it does not exist, e.g.
+// for values that are always set by a middleware in front of the handler
+func MustGetValue[V any, T any](ctx *HttpCtx[T], key *Key[V]) V {
+	value, exists := GetValue(ctx, key)
+	if !exists {
+		panic("no value set for key " + key.name)
+	}
+	return value
+}
+
+// DeleteValue removes the value stored for the key
+func DeleteValue[V any, T any](ctx *HttpCtx[T], key *Key[V]) {
+	delete(ctx.values, key)
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewKey[V any](name string) *Key[V] {
+	return &Key[V]{name: name}
+}
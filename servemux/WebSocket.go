@@ -0,0 +1,566 @@
+package servemux
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/uoul/go-common/serialization"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------------------------------
+
+const (
+	WS_TEXT   = 0x1
+	WS_BINARY = 0x2
+
+	WS_CLOSE_NORMAL           = 1000
+	WS_CLOSE_GOING_AWAY       = 1001
+	WS_CLOSE_PROTOCOL_ERROR   = 1002
+	WS_CLOSE_UNSUPPORTED_DATA = 1003
+	WS_CLOSE_NO_STATUS        = 1005
+	WS_CLOSE_INVALID_PAYLOAD  = 1007
+	WS_CLOSE_POLICY_VIOLATION = 1008
+	WS_CLOSE_MESSAGE_TOO_BIG  = 1009
+	WS_CLOSE_INTERNAL_ERROR   = 1011
+)
+
+const (
+	wsContinuation = 0x0
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+
+	wsGuid         = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsWriteTimeout = 10 * time.Second
+	wsCloseTimeout = time.Second
+
+	wsMaxCloseReason = 123 // Control frame payload (125) without close code
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+type WebSocketConfig struct {
+	subprotocols []string
+	pingInterval time.Duration
+	readLimit    int64
+	checkOrigin  func(r *http.Request) bool
+}
+
+// WebSocketConn is a server side websocket connection (RFC 6455), which sends and receives
+// messages of type M using the serializer of the handler
+type WebSocketConn[M any] struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	writer      *bufio.Writer
+	serializer  serialization.ISerializer
+	subprotocol string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	pingInterval time.Duration
+	readLimit    int64
+
+	writeMux  sync.Mutex
+	closeSent bool
+	reading   atomic.Bool
+	closeOnce sync.Once
+}
+
+// WebSocketCloseError is returned by receive methods, once the connection is closed
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// Error implements error.
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket closed (code=%d, reason=%s)", e.Code, e.Reason)
+}
+
+// Context returns a context, that is canceled when the connection is closed
+func (c *WebSocketConn[M]) Context() context.Context {
+	return c.ctx
+}
+
+// Subprotocol returns the negotiated subprotocol (empty if none)
+func (c *WebSocketConn[M]) Subprotocol() string {
+	return c.subprotocol
+}
+
+// Send serializes the message and sends it as text message
+func (c *WebSocketConn[M]) Send(msg M) error {
+	data, err := c.serializer.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.SendRaw(WS_TEXT, data)
+}
+
+// Receive waits for the next message and deserializes it. Ping, pong and close frames are
+// handled internally, a closed connection is reported as *WebSocketCloseError.
+func (c *WebSocketConn[M]) Receive() (M, error) {
+	_, data, err := c.ReceiveRaw()
+	if err != nil {
+		return *new(M), err
+	}
+	msg := *new(M)
+	if err := c.serializer.Unmarshal(data, &msg); err != nil {
+		return *new(M), err
+	}
+	return msg, nil
+}
+
+// SendRaw sends data as single message of the given type (WS_TEXT or WS_BINARY)
+func (c *WebSocketConn[M]) SendRaw(messageType int, data []byte) error {
+	if messageType != WS_TEXT && messageType != WS_BINARY {
+		return fmt.Errorf("invalid websocket message type %d", messageType)
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// ReceiveRaw waits for the next message and returns its type (WS_TEXT or WS_BINARY) and
+// data. It must not be called concurrently.
+func (c *WebSocketConn[M]) ReceiveRaw() (int, []byte, error) {
+	if !c.reading.CompareAndSwap(false, true) {
+		return 0, nil, fmt.Errorf("concurrent websocket reads are not supported")
+	}
+	defer c.reading.Store(false)
+	return c.readMessage()
+}
+
+// Close starts the close handshake with the given code and reason, waits shortly for the
+// close reply of the client and closes the connection
+func (c *WebSocketConn[M]) Close(code int, reason string) error {
+	err := c.writeClose(code, reason)
+	// Wait for the close reply, if no one else is reading
+	if err == nil && c.reading.CompareAndSwap(false, true) {
+		c.conn.SetReadDeadline(time.Now().Add(wsCloseTimeout))
+		for {
+			if _, _, err := c.readMessage(); err != nil {
+				break
+			}
+		}
+	}
+	c.shutdown()
+	return err
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// WebSocket returns a handler, that upgrades the request to a websocket connection and
+// passes it to the given handler. The connection is closed, when the handler returns.
+func WebSocket[M any](handler func(conn *WebSocketConn[M]), opts ...func(*WebSocketConfig)) HandlerFunc[any] {
+	return func(ctx *HttpCtx[any]) {
+		conn, err := UpgradeWebSocket[M](ctx, opts...)
+		if err != nil {
+			ctx.AbortWithError(err)
+			return
+		}
+		defer conn.Close(WS_CLOSE_NORMAL, "")
+		handler(conn)
+	}
+}
+
+// UpgradeWebSocket performs the websocket handshake and takes over the connection of the
+// request. Nothing is written by the handler chain afterwards. Handshake failures are
+// returned as IHttpError.
+func UpgradeWebSocket[M any, T any](ctx *HttpCtx[T], opts ...func(*WebSocketConfig)) (*WebSocketConn[M], error) {
+	config := NewWebSocketConfig(opts...)
+	req := ctx.req
+	// Validate handshake
+	if req.Method != http.MethodGet {
+		return nil, NewHttpError(http.StatusMethodNotAllowed, "websocket handshake requires GET")
+	}
+	if !headerContainsToken(req.Header, "Connection", "upgrade") || !headerContainsToken(req.Header, "Upgrade", "websocket") {
+		ctx.SetHeader("Upgrade", "websocket")
+		return nil, NewHttpError(http.StatusUpgradeRequired, "websocket upgrade required")
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		ctx.SetHeader("Sec-WebSocket-Version", "13")
+		return nil, NewHttpError(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, NewHttpError(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	if !config.checkOrigin(req) {
+		return nil, NewHttpError(http.StatusForbidden, "websocket origin not allowed")
+	}
+	subprotocol := ""
+	for _, requested := range strings.Split(req.Header.Get("Sec-WebSocket-Protocol"), ",") {
+		if requested = strings.TrimSpace(requested); slices.Contains(config.subprotocols, requested) {
+			subprotocol = requested
+			break
+		}
+	}
+	// Take over connection
+	netConn, rw, err := http.NewResponseController(ctx.respWriter).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hijack connection for websocket - %v", err)
+	}
+	ctx.startStream()
+	accept := sha1.Sum([]byte(key + wsGuid))
+	resp := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"
+	resp += fmt.Sprintf("Sec-WebSocket-Accept: %s\r\n", base64.StdEncoding.EncodeToString(accept[:]))
+	if subprotocol != "" {
+		resp += fmt.Sprintf("Sec-WebSocket-Protocol: %s\r\n", subprotocol)
+	}
+	netConn.SetDeadline(time.Time{})
+	if _, err := rw.WriteString(resp + "\r\n"); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	// Create connection
+	wsCtx, cancel := context.WithCancel(req.Context())
+	conn := &WebSocketConn[M]{
+		conn:        netConn,
+		reader:      rw.Reader,
+		writer:      rw.Writer,
+		serializer:  ctx.respSerializer,
+		subprotocol: subprotocol,
+
+		ctx:    wsCtx,
+		cancel: cancel,
+
+		pingInterval: config.pingInterval,
+		readLimit:    config.readLimit,
+	}
+	go conn.keepAlive()
+	return conn, nil
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Options
+// -----------------------------------------------------------------------------------------------------------
+
+// WithWebSocketSubprotocols sets the supported subprotocols in order of preference
+func WithWebSocketSubprotocols(subprotocols ...string) func(*WebSocketConfig) {
+	return func(wc *WebSocketConfig) {
+		wc.subprotocols = subprotocols
+	}
+}
+
+// WithWebSocketPingInterval sets the interval of keepalive pings (default 30s, 0 disables).
+// Connections without any frame from the client within two intervals are closed.
+func WithWebSocketPingInterval(interval time.Duration) func(*WebSocketConfig) {
+	return func(wc *WebSocketConfig) {
+		wc.pingInterval = interval
+	}
+}
+
+// WithWebSocketReadLimit sets the maximum size of a received message (default 1MB)
+func WithWebSocketReadLimit(limit int64) func(*WebSocketConfig) {
+	return func(wc *WebSocketConfig) {
+		wc.readLimit = limit
+	}
+}
+
+// WithWebSocketOriginCheck replaces the default check, which only allows requests without
+// Origin header or with an Origin matching the Host header
+func WithWebSocketOriginCheck(check func(r *http.Request) bool) func(*WebSocketConfig) {
+	return func(wc *WebSocketConfig) {
+		wc.checkOrigin = check
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func (c *WebSocketConn[M]) readMessage() (int, []byte, error) {
+	messageType := 0
+	message := []byte{}
+	for {
+		if c.pingInterval > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(2 * c.pingInterval))
+		}
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			var closeErr *WebSocketCloseError
+			if errors.As(err, &closeErr) {
+				c.writeClose(closeErr.Code, closeErr.Reason)
+			}
+			c.shutdown()
+			return 0, nil, err
+		}
+		switch opcode {
+		case wsPing:
+			c.writeFrame(wsPong, payload)
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			closeErr := &WebSocketCloseError{Code: WS_CLOSE_NO_STATUS}
+			switch {
+			case len(payload) == 0:
+				c.writeClose(WS_CLOSE_NORMAL, "")
+			case len(payload) == 1:
+				return 0, nil, c.fail(WS_CLOSE_PROTOCOL_ERROR, "invalid close payload")
+			default:
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+				if !validCloseCode(closeErr.Code) {
+					return 0, nil, c.fail(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")
+				}
+				if !utf8.ValidString(closeErr.Reason) {
+					return 0, nil, c.fail(WS_CLOSE_INVALID_PAYLOAD, "invalid utf-8")
+				}
+				c.writeClose(closeErr.Code, "")
+			}
+			c.shutdown()
+			return 0, nil, closeErr
+		case WS_TEXT, WS_BINARY:
+			if messageType != 0 {
+				return 0, nil, c.fail(WS_CLOSE_PROTOCOL_ERROR, "expected continuation frame")
+			}
+			messageType = int(opcode)
+		case wsContinuation:
+			if messageType == 0 {
+				return 0, nil, c.fail(WS_CLOSE_PROTOCOL_ERROR, "unexpected continuation frame")
+			}
+		default:
+			return 0, nil, c.fail(WS_CLOSE_PROTOCOL_ERROR, "unknown opcode")
+		}
+		if int64(len(message)+len(payload)) > c.readLimit {
+			return 0, nil, c.fail(WS_CLOSE_MESSAGE_TOO_BIG, "message too big")
+		}
+		message = append(message, payload...)
+		if fin {
+			if messageType == WS_TEXT && !utf8.Valid(message) {
+				return 0, nil, c.fail(WS_CLOSE_INVALID_PAYLOAD, "invalid utf-8")
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+func (c *WebSocketConn[M]) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, &WebSocketCloseError{Code: WS_CLOSE_PROTOCOL_ERROR, Reason: "reserved bits set"}
+	}
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, &WebSocketCloseError{Code: WS_CLOSE_PROTOCOL_ERROR, Reason: "client frames must be masked"}
+	}
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if opcode >= wsClose && (!fin || length > 125) {
+		return false, 0, nil, &WebSocketCloseError{Code: WS_CLOSE_PROTOCOL_ERROR, Reason: "invalid control frame"}
+	}
+	if length > uint64(c.readLimit) {
+		return false, 0, nil, &WebSocketCloseError{Code: WS_CLOSE_MESSAGE_TOO_BIG, Reason: "message too big"}
+	}
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, mask); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
+
+func (c *WebSocketConn[M]) writeFrame(opcode byte, payload []byte) error {
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	if c.closeSent {
+		return &WebSocketCloseError{Code: WS_CLOSE_NORMAL, Reason: "connection is closing"}
+	}
+	return c.writeFrameLocked(opcode, payload)
+}
+
+func (c *WebSocketConn[M]) writeFrameLocked(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length <= 125:
+		header = append(header, byte(length))
+	case length <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.writer.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.writer.Write(payload); err != nil {
+		return err
+	}
+	return c.writer.Flush()
+}
+
+// writeClose sends a close frame once, afterwards no further frames are sent. Codes, that
+// must not be sent (e.g. 1005), result in a close frame without status. The reason is
+// truncated to fit into a control frame.
+func (c *WebSocketConn[M]) writeClose(code int, reason string) error {
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	if c.closeSent {
+		return nil
+	}
+	c.closeSent = true
+	payload := []byte{}
+	if validCloseCode(code) {
+		payload = binary.BigEndian.AppendUint16(payload, uint16(code))
+		payload = append(payload, truncateUtf8(strings.ToValidUTF8(reason, ""), wsMaxCloseReason)...)
+	}
+	return c.writeFrameLocked(wsClose, payload)
+}
+
+// fail closes the connection because of a protocol violation of the client
+func (c *WebSocketConn[M]) fail(code int, reason string) error {
+	c.writeClose(code, reason)
+	c.shutdown()
+	return &WebSocketCloseError{Code: code, Reason: reason}
+}
+
+func (c *WebSocketConn[M]) shutdown() {
+	c.closeOnce.Do(func() {
+		c.cancel()
+		c.conn.Close()
+	})
+}
+
+// keepAlive sends pings and closes the connection, when the request context is canceled
+// or the server shuts down
+func (c *WebSocketConn[M]) keepAlive() {
+	var ping <-chan time.Time
+	if c.pingInterval > 0 {
+		ticker := time.NewTicker(c.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.writeClose(WS_CLOSE_GOING_AWAY, "")
+			c.shutdown()
+			return
+		case <-serverShutdown(c.ctx):
+			c.writeClose(WS_CLOSE_GOING_AWAY, "server shutdown")
+			c.shutdown()
+			return
+		case <-ping:
+			if err := c.writeFrame(wsPing, nil); err != nil {
+				c.shutdown()
+				return
+			}
+		}
+	}
+}
+
+// validCloseCode reports whether the code may be sent in a close frame (RFC 6455 7.4)
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code < 1000 || code > 1014:
+		return false
+	}
+	return code != 1004 && code != WS_CLOSE_NO_STATUS && code != 1006
+}
+
+// truncateUtf8 cuts s to at most n bytes without splitting a character
+func truncateUtf8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func headerContainsToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Constructor
+// -----------------------------------------------------------------------------------------------------------
+
+func NewWebSocketConfig(opts ...func(*WebSocketConfig)) *WebSocketConfig {
+	c := &WebSocketConfig{
+		subprotocols: []string{},
+		pingInterval: 30 * time.Second,
+		readLimit:    1 << 20, // 1MB
+		checkOrigin:  checkSameOrigin,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
@@ -0,0 +1,290 @@
+package servemux
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/uoul/go-common/serialization"
+)
+
+type wsTestFrame struct {
+	fin     bool
+	opcode  byte
+	payload []byte
+}
+
+func TestWebSocketReceive(t *testing.T) {
+	tests := []struct {
+		name     string
+		frames   [][]byte
+		wantType int
+		wantData string
+		wantCode int // Code of the returned close error (0 for none)
+		replies  []wsTestFrame
+	}{
+		{"masked text", [][]byte{wsClientFrame(true, WS_TEXT, []byte("hello"))}, WS_TEXT, "hello", 0, nil},
+		{"masked binary", [][]byte{wsClientFrame(true, WS_BINARY, []byte{0, 1, 2})}, WS_BINARY, "\x00\x01\x02", 0, nil},
+		{"extended length", [][]byte{wsClientFrame(true, WS_TEXT, bytes.Repeat([]byte("a"), 300))}, WS_TEXT, strings.Repeat("a", 300), 0, nil},
+		{"fragmented message", [][]byte{
+			wsClientFrame(false, WS_TEXT, []byte("hel")),
+			wsClientFrame(false, wsContinuation, []byte("l")),
+			wsClientFrame(true, wsContinuation, []byte("o")),
+		}, WS_TEXT, "hello", 0, nil},
+		{"ping between fragments", [][]byte{
+			wsClientFrame(false, WS_TEXT, []byte("hel")),
+			wsClientFrame(true, wsPing, []byte("ping")),
+			wsClientFrame(true, wsContinuation, []byte("lo")),
+		}, WS_TEXT, "hello", 0, []wsTestFrame{{true, wsPong, []byte("ping")}}},
+		{"pong is ignored", [][]byte{
+			wsClientFrame(true, wsPong, nil),
+			wsClientFrame(true, WS_TEXT, []byte("hello")),
+		}, WS_TEXT, "hello", 0, nil},
+		{"unmasked frame", [][]byte{wsFrame(true, WS_TEXT, []byte("hello"))}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "client frames must be masked")}},
+		{"reserved bits", [][]byte{wsWithFirstByte(wsClientFrame(true, WS_TEXT, nil), 0x40)}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "reserved bits set")}},
+		{"fragmented control frame", [][]byte{wsClientFrame(false, wsPing, nil)}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid control frame")}},
+		{"control frame too long", [][]byte{wsClientFrame(true, wsPing, make([]byte, 126))}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid control frame")}},
+		{"unexpected continuation", [][]byte{wsClientFrame(true, wsContinuation, nil)}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "unexpected continuation frame")}},
+		{"interleaved data frames", [][]byte{
+			wsClientFrame(false, WS_TEXT, []byte("hel")),
+			wsClientFrame(true, WS_TEXT, []byte("lo")),
+		}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "expected continuation frame")}},
+		{"unknown opcode", [][]byte{wsClientFrame(true, 0x3, nil)}, 0, "", WS_CLOSE_PROTOCOL_ERROR, []wsTestFrame{wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "unknown opcode")}},
+		{"invalid utf-8 text", [][]byte{wsClientFrame(true, WS_TEXT, []byte{0xff})}, 0, "", WS_CLOSE_INVALID_PAYLOAD, []wsTestFrame{wsCloseFrame(WS_CLOSE_INVALID_PAYLOAD, "invalid utf-8")}},
+		{"message too big", [][]byte{wsClientFrame(true, WS_BINARY, make([]byte, 2048))}, 0, "", WS_CLOSE_MESSAGE_TOO_BIG, []wsTestFrame{wsCloseFrame(WS_CLOSE_MESSAGE_TOO_BIG, "message too big")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := newTestWebSocket(1024)
+			replies := wsReadFrames(client)
+			go func() {
+				for _, frame := range tt.frames {
+					if _, err := client.Write(frame); err != nil {
+						return
+					}
+				}
+			}()
+			messageType, data, err := conn.ReceiveRaw()
+			conn.shutdown()
+			if tt.wantCode != 0 {
+				var closeErr *WebSocketCloseError
+				if !errors.As(err, &closeErr) || closeErr.Code != tt.wantCode {
+					t.Fatalf("got error %v, want close code %d", err, tt.wantCode)
+				}
+			} else if err != nil || messageType != tt.wantType || string(data) != tt.wantData {
+				t.Fatalf("got type %d, %d bytes, error %v, want type %d, %d bytes", messageType, len(data), err, tt.wantType, len(tt.wantData))
+			}
+			wsAssertFrames(t, <-replies, tt.replies)
+		})
+	}
+}
+
+func TestWebSocketCloseHandshake(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   []byte
+		wantCode  int
+		wantReply wsTestFrame
+	}{
+		{"close with code and reason", wsClosePayload(WS_CLOSE_GOING_AWAY, "bye"), WS_CLOSE_GOING_AWAY, wsCloseFrame(WS_CLOSE_GOING_AWAY, "")},
+		{"close without status", nil, WS_CLOSE_NO_STATUS, wsCloseFrame(WS_CLOSE_NORMAL, "")},
+		{"application close code", wsClosePayload(4000, ""), 4000, wsCloseFrame(4000, "")},
+		{"one byte payload", []byte{0x03}, WS_CLOSE_PROTOCOL_ERROR, wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid close payload")},
+		{"code 1005 must not be sent", wsClosePayload(WS_CLOSE_NO_STATUS, ""), WS_CLOSE_PROTOCOL_ERROR, wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")},
+		{"code 1006 must not be sent", wsClosePayload(1006, ""), WS_CLOSE_PROTOCOL_ERROR, wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")},
+		{"code 1015 must not be sent", wsClosePayload(1015, ""), WS_CLOSE_PROTOCOL_ERROR, wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")},
+		{"code below 1000", wsClosePayload(999, ""), WS_CLOSE_PROTOCOL_ERROR, wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")},
+		{"unassigned code", wsClosePayload(2000, ""), WS_CLOSE_PROTOCOL_ERROR, wsCloseFrame(WS_CLOSE_PROTOCOL_ERROR, "invalid close code")},
+		{"invalid utf-8 reason", wsClosePayload(WS_CLOSE_NORMAL, "\xff"), WS_CLOSE_INVALID_PAYLOAD, wsCloseFrame(WS_CLOSE_INVALID_PAYLOAD, "invalid utf-8")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := newTestWebSocket(1 << 20)
+			replies := wsReadFrames(client)
+			go client.Write(wsClientFrame(true, wsClose, tt.payload))
+			_, _, err := conn.ReceiveRaw()
+			var closeErr *WebSocketCloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != tt.wantCode {
+				t.Fatalf("got error %v, want close code %d", err, tt.wantCode)
+			}
+			wsAssertFrames(t, <-replies, []wsTestFrame{tt.wantReply})
+			// No frames are sent after the close frame
+			if err := conn.SendRaw(WS_TEXT, []byte("late")); err == nil {
+				t.Error("expected send after close to fail")
+			}
+		})
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		reason  string
+		payload []byte
+	}{
+		{"code and reason", WS_CLOSE_NORMAL, "done", wsClosePayload(WS_CLOSE_NORMAL, "done")},
+		{"long reason is truncated", WS_CLOSE_NORMAL, strings.Repeat("a", 200), wsClosePayload(WS_CLOSE_NORMAL, strings.Repeat("a", 123))},
+		{"truncation keeps characters whole", WS_CLOSE_NORMAL, "a" + strings.Repeat("ä", 100), wsClosePayload(WS_CLOSE_NORMAL, "a"+strings.Repeat("ä", 61))},
+		{"invalid utf-8 is removed", WS_CLOSE_NORMAL, "a\xffb", wsClosePayload(WS_CLOSE_NORMAL, "ab")},
+		{"no status", WS_CLOSE_NO_STATUS, "ignored", []byte{}},
+		{"reserved code sends no status", 1006, "ignored", []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, client := newTestWebSocket(1 << 20)
+			replies := wsReadFrames(client)
+			done := make(chan error)
+			go func() { done <- conn.Close(tt.code, tt.reason) }()
+			// Client answers the close frame
+			if _, err := client.Write(wsClientFrame(true, wsClose, wsClosePayload(WS_CLOSE_NORMAL, ""))); err != nil {
+				t.Fatal(err)
+			}
+			if err := <-done; err != nil {
+				t.Fatalf("close failed: %v", err)
+			}
+			frames := <-replies
+			wsAssertFrames(t, frames, []wsTestFrame{{true, wsClose, tt.payload}})
+			if len(frames) > 0 && (len(frames[0].payload) > 125 || (len(frames[0].payload) > 2 && !utf8.Valid(frames[0].payload[2:]))) {
+				t.Errorf("invalid close payload %q", frames[0].payload)
+			}
+		})
+	}
+}
+
+func TestWebSocketSendFraming(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 65535, 65536} {
+		conn, client := newTestWebSocket(1 << 20)
+		replies := wsReadFrames(client)
+		payload := bytes.Repeat([]byte("x"), size)
+		if err := conn.SendRaw(WS_BINARY, payload); err != nil {
+			t.Fatalf("send of %d bytes failed: %v", size, err)
+		}
+		conn.shutdown()
+		// Server frames are sent unmasked (checked by wsReadFrames)
+		wsAssertFrames(t, <-replies, []wsTestFrame{{true, WS_BINARY, payload}})
+	}
+}
+
+// -----------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------
+
+// newTestWebSocket returns a server connection and the client end of an in-memory pipe
+func newTestWebSocket(readLimit int64) (*WebSocketConn[string], net.Conn) {
+	server, client := net.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebSocketConn[string]{
+		conn:       server,
+		reader:     bufio.NewReader(server),
+		writer:     bufio.NewWriter(server),
+		serializer: serialization.NewJSONSerializer(),
+		ctx:        ctx,
+		cancel:     cancel,
+		readLimit:  readLimit,
+	}, client
+}
+
+// wsReadFrames collects all frames sent by the server, until the connection is closed
+func wsReadFrames(client net.Conn) <-chan []wsTestFrame {
+	result := make(chan []wsTestFrame, 1)
+	go func() {
+		frames := []wsTestFrame{}
+		reader := bufio.NewReader(client)
+		for {
+			client.SetReadDeadline(time.Now().Add(5 * time.Second))
+			header := make([]byte, 2)
+			if _, err := io.ReadFull(reader, header); err != nil {
+				break
+			}
+			if header[1]&0x80 != 0 {
+				frames = append(frames, wsTestFrame{opcode: 0xff, payload: []byte("masked server frame")})
+				break
+			}
+			length := uint64(header[1] & 0x7f)
+			switch length {
+			case 126:
+				ext := make([]byte, 2)
+				io.ReadFull(reader, ext)
+				length = uint64(binary.BigEndian.Uint16(ext))
+			case 127:
+				ext := make([]byte, 8)
+				io.ReadFull(reader, ext)
+				length = binary.BigEndian.Uint64(ext)
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				break
+			}
+			frames = append(frames, wsTestFrame{header[0]&0x80 != 0, header[0] & 0x0f, payload})
+		}
+		client.Close()
+		result <- frames
+	}()
+	return result
+}
+
+func wsAssertFrames(t *testing.T, got []wsTestFrame, want []wsTestFrame) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d frames from server, want %d (%v)", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].fin != want[i].fin || got[i].opcode != want[i].opcode || !bytes.Equal(got[i].payload, want[i].payload) {
+			t.Errorf("frame %d = fin %v, opcode %d, payload %q, want fin %v, opcode %d, payload %q", i, got[i].fin, got[i].opcode, got[i].payload, want[i].fin, want[i].opcode, want[i].payload)
+		}
+	}
+}
+
+func wsCloseFrame(code int, reason string) wsTestFrame {
+	return wsTestFrame{true, wsClose, wsClosePayload(code, reason)}
+}
+
+func wsClosePayload(code int, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(code)), reason...)
+}
+
+// wsClientFrame creates a masked frame, like sent by clients
+func wsClientFrame(fin bool, opcode byte, payload []byte) []byte {
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame := wsHeader(fin, opcode, len(payload))
+	frame[1] |= 0x80
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// wsFrame creates an unmasked frame
+func wsFrame(fin bool, opcode byte, payload []byte) []byte {
+	return append(wsHeader(fin, opcode, len(payload)), payload...)
+}
+
+func wsHeader(fin bool, opcode byte, length int) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	switch {
+	case length <= 125:
+		return []byte{first, byte(length)}
+	case length <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{first, 126}, uint16(length))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{first, 127}, uint64(length))
+	}
+}
+
+func wsWithFirstByte(frame []byte, bits byte) []byte {
+	frame[0] |= bits
+	return frame
+}
@@ -0,0 +1,39 @@
+package tracing
+
+import "context"
+
+// -----------------------------------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------------------------------
+
+const (
+	REQUEST_ID_HEADER  = "X-Request-ID"
+	TRACEPARENT_HEADER = "traceparent"
+)
+
+type requestIdKey struct{}
+type traceParentKey struct{}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId returns the request id stored in the context ("" if none)
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+func WithTraceParent(ctx context.Context, traceParent TraceParent) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// GetTraceParent returns the trace context of the current span stored in the context
+func GetTraceParent(ctx context.Context) (TraceParent, bool) {
+	traceParent, ok := ctx.Value(traceParentKey{}).(TraceParent)
+	return traceParent, ok
+}
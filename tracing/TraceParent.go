@@ -0,0 +1,110 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// TraceParent is the trace context of the W3C traceparent header
+// ("00-<trace-id>-<parent-id>-<flags>")
+type TraceParent struct {
+	TraceId  string
+	ParentId string
+	Flags    byte
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public
+// -----------------------------------------------------------------------------------------------------------
+
+// String returns the traceparent header value
+func (t TraceParent) String() string {
+	return fmt.Sprintf("00-%s-%s-%02x", t.TraceId, t.ParentId, t.Flags)
+}
+
+// Sampled returns whether the sampled flag is set
+func (t TraceParent) Sampled() bool {
+	return t.Flags&0x01 != 0
+}
+
+// Child returns the trace context for a new span of the same trace
+func (t TraceParent) Child() TraceParent {
+	return TraceParent{
+		TraceId:  t.TraceId,
+		ParentId: randomHex(8),
+		Flags:    t.Flags,
+	}
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// ParseTraceParent parses a traceparent header value. Future versions are accepted as
+// long as they start with the fields of version 00.
+func ParseTraceParent(value string) (TraceParent, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	version, traceId, parentId, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || !isHex(traceId, 32) || !isHex(parentId, 16) || !isHex(flags, 2) {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q", value)
+	}
+	if strings.Trim(traceId, "0") == "" || strings.Trim(parentId, "0") == "" {
+		return TraceParent{}, fmt.Errorf("invalid traceparent %q - ids must not be zero", value)
+	}
+	flagsByte, _ := hex.DecodeString(flags)
+	return TraceParent{
+		TraceId:  traceId,
+		ParentId: parentId,
+		Flags:    flagsByte[0],
+	}, nil
+}
+
+// NewTraceParent starts a new sampled trace
+func NewTraceParent() TraceParent {
+	return TraceParent{
+		TraceId:  randomHex(16),
+		ParentId: randomHex(8),
+		Flags:    0x01,
+	}
+}
+
+// NewRequestId returns a random request id (UUID version 4)
+func NewRequestId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func randomHex(size int) string {
+	b := make([]byte, size)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// isHex checks for lowercase hex strings of the given length
+func isHex(value string, length int) bool {
+	if len(value) != length {
+		return false
+	}
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
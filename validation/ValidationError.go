@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+)
+
+type FieldError struct {
+	Field  string `json:"field" xml:"field" yaml:"field"`
+	Reason string `json:"reason" xml:"reason" yaml:"reason"`
+}
+
+type ValidationError struct {
+	Errors []FieldError `json:"errors" xml:"error" yaml:"errors"`
+}
+
+// Error implements error.
+func (v *ValidationError) Error() string {
+	msgs := make([]string, len(v.Errors))
+	for i, e := range v.Errors {
+		msgs[i] = fmt.Sprintf("%s: %s", e.Field, e.Reason)
+	}
+	return fmt.Sprintf("validation failed - %s", strings.Join(msgs, "; "))
+}
+
+func NewValidationError(errors []FieldError) error {
+	return &ValidationError{
+		Errors: errors,
+	}
+}
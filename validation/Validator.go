@@ -0,0 +1,263 @@
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// -----------------------------------------------------------------------------------------------------------
+// Constant's
+// -----------------------------------------------------------------------------------------------------------
+
+const (
+	TAG = "validate"
+)
+
+var regexCache sync.Map
+
+// -----------------------------------------------------------------------------------------------------------
+// Types
+// -----------------------------------------------------------------------------------------------------------
+
+// Rule is a single rule of a `validate` tag, e.g. "min=3" has name "min" and param "3"
+type Rule struct {
+	Name  string
+	Param string
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Public Functions
+// -----------------------------------------------------------------------------------------------------------
+
+// Validate checks v (struct or pointer to struct) against the rules given in its `validate`
+// struct tags and returns a *ValidationError listing every invalid field, or nil.
+// Nested structs, pointers, slices and maps of structs are validated recursively.
+//
+// Rules (comma separated):
+//   - required: value must not be the zero value (nil, "", 0, empty slice/map)
+//   - min=N, max=N: lower/upper bound for numbers, minimum/maximum length for strings, slices and maps
+//   - len=N: exact length for strings, slices and maps
+//   - enum=a|b|c: value must be one of the given values
+//   - regex=PATTERN: string must match the pattern (has to be the last rule, may contain commas)
+//
+// Field paths use the json name of a field, if given (e.g. "items[0].name").
+func Validate(v any) error {
+	errors := []FieldError{}
+	validateValue(reflect.ValueOf(v), "", &errors)
+	if len(errors) > 0 {
+		return NewValidationError(errors)
+	}
+	return nil
+}
+
+// ParseRules splits the `validate` tag into its rules as applied by Validate. The tag is
+// split by comma, except for the regex rule, which takes the rest of the tag.
+func ParseRules(tag string) []Rule {
+	rules := []Rule{}
+	for tag = strings.TrimSpace(tag); tag != ""; tag = strings.TrimSpace(tag) {
+		if strings.HasPrefix(tag, "regex=") {
+			rules = append(rules, Rule{Name: "regex", Param: strings.TrimPrefix(tag, "regex=")})
+			break
+		}
+		rule, rest, _ := strings.Cut(tag, ",")
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		rules = append(rules, Rule{Name: name, Param: param})
+		tag = rest
+	}
+	return rules
+}
+
+// -----------------------------------------------------------------------------------------------------------
+// Private
+// -----------------------------------------------------------------------------------------------------------
+
+func validateValue(v reflect.Value, path string, errors *[]FieldError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := joinPath(path, fieldName(field))
+			fieldValue := v.Field(i)
+			if tag, exists := field.Tag.Lookup(TAG); exists && tag != "-" {
+				if reason := validateField(fieldValue, tag); reason != "" {
+					*errors = append(*errors, FieldError{Field: fieldPath, Reason: reason})
+					continue
+				}
+			}
+			validateValue(fieldValue, fieldPath, errors)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errors)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key().Interface()), errors)
+		}
+	}
+}
+
+// validateField applies all rules of the tag to the value and returns the reason of the
+// first violated rule, or "" if the value is valid
+func validateField(v reflect.Value, tag string) string {
+	rules := ParseRules(tag)
+	// Optional nil pointers are valid, otherwise validate the value pointed to
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if containsRule(rules, "required") {
+				return "is required"
+			}
+			return ""
+		}
+		v = v.Elem()
+	}
+	for _, rule := range rules {
+		name, param := rule.Name, rule.Param
+		var reason string
+		switch name {
+		case "required":
+			if v.IsZero() {
+				reason = "is required"
+			}
+		case "min":
+			reason = checkBound(v, param, func(value, bound float64) bool { return value >= bound }, "must be at least %s", "must have at least %s %s")
+		case "max":
+			reason = checkBound(v, param, func(value, bound float64) bool { return value <= bound }, "must be at most %s", "must have at most %s %s")
+		case "len":
+			reason = checkLen(v, param)
+		case "enum":
+			reason = checkEnum(v, param)
+		case "regex":
+			reason = checkRegex(v, param)
+		case "":
+		default:
+			reason = fmt.Sprintf("unknown validation rule %q", name)
+		}
+		if reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func checkBound(v reflect.Value, param string, ok func(value, bound float64) bool, numberMsg, lengthMsg string) string {
+	bound, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return fmt.Sprintf("invalid validation parameter %q", param)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !ok(float64(v.Int()), bound) {
+			return fmt.Sprintf(numberMsg, param)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if !ok(float64(v.Uint()), bound) {
+			return fmt.Sprintf(numberMsg, param)
+		}
+	case reflect.Float32, reflect.Float64:
+		if !ok(v.Float(), bound) {
+			return fmt.Sprintf(numberMsg, param)
+		}
+	case reflect.String:
+		if !ok(float64(len([]rune(v.String()))), bound) {
+			return fmt.Sprintf(lengthMsg, param, "characters")
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if !ok(float64(v.Len()), bound) {
+			return fmt.Sprintf(lengthMsg, param, "elements")
+		}
+	default:
+		return fmt.Sprintf("rule is not supported for type %s", v.Type())
+	}
+	return ""
+}
+
+func checkLen(v reflect.Value, param string) string {
+	length, err := strconv.Atoi(param)
+	if err != nil {
+		return fmt.Sprintf("invalid validation parameter %q", param)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if len([]rune(v.String())) != length {
+			return fmt.Sprintf("must have exactly %d characters", length)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if v.Len() != length {
+			return fmt.Sprintf("must have exactly %d elements", length)
+		}
+	default:
+		return fmt.Sprintf("rule is not supported for type %s", v.Type())
+	}
+	return ""
+}
+
+func checkEnum(v reflect.Value, param string) string {
+	allowed := strings.Split(param, "|")
+	value := fmt.Sprint(v.Interface())
+	for _, a := range allowed {
+		if a == value {
+			return ""
+		}
+	}
+	return fmt.Sprintf("must be one of [%s]", strings.Join(allowed, ", "))
+}
+
+func checkRegex(v reflect.Value, param string) string {
+	if v.Kind() != reflect.String {
+		return fmt.Sprintf("rule is not supported for type %s", v.Type())
+	}
+	var re *regexp.Regexp
+	if cached, exists := regexCache.Load(param); exists {
+		re = cached.(*regexp.Regexp)
+	} else {
+		compiled, err := regexp.Compile(param)
+		if err != nil {
+			return fmt.Sprintf("invalid validation pattern %q", param)
+		}
+		regexCache.Store(param, compiled)
+		re = compiled
+	}
+	if !re.MatchString(v.String()) {
+		return fmt.Sprintf("must match pattern %s", param)
+	}
+	return ""
+}
+
+func containsRule(rules []Rule, name string) bool {
+	for _, r := range rules {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}